@@ -30,6 +30,7 @@ import (
 var (
 	nodeId    string
 	peerList  string
+	rackId    string
 	sourceCfg string
 	targetCfg string
 )
@@ -37,12 +38,13 @@ var (
 func init() {
 	flag.StringVar(&nodeId, "node-id", "", "the node id for the current aerospike node")
 	flag.StringVar(&peerList, "peer-list", "", "comma-separated list of peers for the current aerospike node")
+	flag.StringVar(&rackId, "rack-id", "", "the rack id for the current aerospike node")
 	flag.StringVar(&sourceCfg, "source-config", "", "path to the source configuration file")
 	flag.StringVar(&targetCfg, "target-config", "", "path to the target configuration file")
 }
 
-// asinit takes a node id and a list of peers for a given aerospike
-// node and updates the source configuration file with these values.
+// asinit takes a node id, a list of peers and a rack id for a given
+// aerospike node and updates the source configuration file with these values.
 // this allows for setting node-specific configuration parameter
 // which can't be set using the common configmap.
 func main() {
@@ -72,6 +74,7 @@ func main() {
 	cfg := string(input)
 	cfg = strings.Replace(cfg, reconciler.ServiceNodeIdValue, nodeId, -1)
 	cfg = strings.Replace(cfg, reconciler.HeartbeatAddressesValue, peers.String(), -1)
+	cfg = strings.Replace(cfg, reconciler.NamespaceRackIdValue, rackId, -1)
 
 	// create the target configuration file
 	if err := ioutil.WriteFile(targetCfg, []byte(cfg), 0777); err != nil {
@@ -26,6 +26,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	extsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -45,15 +46,19 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	v1alpha2converters "github.com/travelaudience/aerospike-operator/pkg/crd/converters/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/signals"
 	flagutils "github.com/travelaudience/aerospike-operator/pkg/utils/flags"
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 const (
-	admissionEnabledFlag = "admission-enabled"
-	debugEnabledFlag     = "debug"
-	kubeconfigFlag       = "kubeconfig"
+	admissionEnabledFlag               = "admission-enabled"
+	aerospikeServerImageRepositoryFlag = "aerospike-server-image-repository"
+	debugEnabledFlag                   = "debug"
+	kubeconfigFlag                     = "kubeconfig"
+	maxNamespacesFlag                  = "max-namespaces"
+	toolsImageRepositoryFlag           = "tools-image-repository"
 )
 
 var (
@@ -67,6 +72,9 @@ func init() {
 	fs.BoolVar(&debug.DebugEnabled, debugEnabledFlag, false, "[DEPRECATED] Whether to enable debug mode.")
 	fs.StringVar(&kubeconfig, kubeconfigFlag, "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	fs.BoolVar(&admission.Enabled, admissionEnabledFlag, true, "[DEPRECATED] Whether to enable the validating admission webhook.")
+	fs.StringVar(&images.AerospikeServerRepository, aerospikeServerImageRepositoryFlag, images.AerospikeServerRepository, "The repository from which to pull the Aerospike Server image. Can be overridden per Aerospike cluster.")
+	fs.IntVar(&admission.MaxNamespaces, maxNamespacesFlag, admission.MaxNamespaces, "The maximum number of Aerospike namespaces an Aerospike cluster may have.")
+	fs.StringVar(&images.ToolsRepository, toolsImageRepositoryFlag, images.ToolsRepository, "The repository from which to pull the aerospike-operator-tools image. Can be overridden per Aerospike cluster.")
 }
 
 func main() {
@@ -74,6 +82,9 @@ func main() {
 
 	// warn about deprecated flags
 	flagutils.DeprecateFlags(fs, admissionEnabledFlag, debugEnabledFlag)
+	if admission.MaxNamespaces < 1 {
+		log.Fatalf("--%s must be greater than zero", maxNamespacesFlag)
+	}
 
 	// workaround for https://github.com/kubernetes/kubernetes/issues/17162
 	flag.CommandLine.Parse([]string{})
@@ -127,6 +138,15 @@ func main() {
 	}
 	go wh.Run(shCh)
 
+	// cancel ctx on the first shutdown signal so that leader election and the
+	// controllers are stopped gracefully
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-shCh
+		cancel()
+	}()
+
 	log.Info("attempting to become leader")
 
 	// setup a resourcelock for leader election
@@ -141,35 +161,51 @@ func main() {
 			EventRecorder: createRecorder(kubeClient, name, namespace),
 		},
 	)
-	// run leader election
-	leaderelection.RunOrDie(context.Background(), leaderelection.LeaderElectionConfig{
-		Lock:          rl,
-		LeaseDuration: 15 * time.Second,
-		RenewDeadline: 10 * time.Second,
-		RetryPeriod:   2 * time.Second,
-		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: func(ctx context.Context) {
-				log.Info("started leading")
-				// stop the controllers when either leCh or shCh are closed
-				stopCh := make(chan struct{})
-				go func() {
-					select {
-					case <-ctx.Done():
-						close(stopCh)
-					case <-shCh:
-						close(stopCh)
-					}
-				}()
-				run(stopCh, cfg, kubeClient, aerospikeClient)
-			},
-			OnStoppedLeading: func() {
-				log.Fatalf("stopped leading")
+	// run leader election in the background, and run the controllers in the
+	// foreground once we become leader so that we can wait for them to stop
+	leadingCh := make(chan context.Context, 1)
+	leDoneCh := make(chan struct{})
+	go func() {
+		defer close(leDoneCh)
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:          rl,
+			LeaseDuration: 15 * time.Second,
+			RenewDeadline: 10 * time.Second,
+			RetryPeriod:   2 * time.Second,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leCtx context.Context) {
+					leadingCh <- leCtx
+				},
+				OnStoppedLeading: func() {
+					log.Info("stopped leading")
+				},
+				OnNewLeader: func(id string) {
+					log.Infof("current leader: %s", id)
+				},
 			},
-			OnNewLeader: func(id string) {
-				log.Infof("current leader: %s", id)
-			},
-		},
-	})
+		})
+	}()
+
+	select {
+	case leCtx := <-leadingCh:
+		log.Info("started leading")
+		// leCtx is cancelled when either leadership is lost or a shutdown
+		// signal is received, in which case the controllers abort any
+		// in-flight operations so that they can be resumed by the next leader
+		run(leCtx, cfg, kubeClient, aerospikeClient)
+	case <-leDoneCh:
+	}
+
+	// exit with a non-zero code if leadership has been lost unexpectedly so
+	// that we are restarted and can attempt to become leader again
+	if ctx.Err() == nil {
+		log.Fatalf("lost leadership")
+	}
+
+	// confirm successful shutdown
+	log.WithFields(log.Fields{
+		"version": versioning.OperatorVersion,
+	}).Infof("aerospike-operator has been shut down")
 }
 
 func createRecorder(kubeClient kubernetes.Interface, name, namespace string) record.EventRecorder {
@@ -179,7 +215,7 @@ func createRecorder(kubeClient kubernetes.Interface, name, namespace string) rec
 	return eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: name})
 }
 
-func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Clientset, aerospikeClient *aerospikeclientset.Clientset) {
+func run(ctx context.Context, cfg *restclient.Config, kubeClient *kubernetes.Clientset, aerospikeClient *aerospikeclientset.Clientset) {
 	extsClient, err := extsclientset.NewForConfig(cfg)
 	if err != nil {
 		log.Fatalf("failed to create apiextensions clientset: %v", err)
@@ -188,6 +224,11 @@ func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Cl
 		log.Fatalf("failed to create custom resource definitions: %v", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		log.Fatalf("failed to create dynamic client: %v", err)
+	}
+
 	aerospikescheme.AddToScheme(scheme.Scheme)
 
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
@@ -203,11 +244,13 @@ func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Cl
 		log.Fatalf("failed to upgrade existing resources to v1alpha2: %v", err)
 	}
 
-	clusterController := controller.NewAerospikeClusterController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
+	clusterController := controller.NewAerospikeClusterController(kubeClient, aerospikeClient, dynamicClient, kubeInformerFactory, aerospikeInformerFactory)
 	backupController := controller.NewAerospikeNamespaceBackupController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 	restoreController := controller.NewAerospikeNamespaceRestoreController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 	gcController := controller.NewGarbageCollectorController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 
+	stopCh := ctx.Done()
+
 	// start the shared informer factories
 	go kubeInformerFactory.Start(stopCh)
 	go aerospikeInformerFactory.Start(stopCh)
@@ -227,14 +270,4 @@ func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Cl
 
 	// wait for controllers to stop
 	wg.Wait()
-
-	// confirm successful shutdown
-	log.WithFields(log.Fields{
-		"version": versioning.OperatorVersion,
-	}).Infof("aerospike-operator has been shut down")
-
-	// there is a goroutine in the background that is trying to renew the leader
-	// election lock. as such we must manually exit now that we know controllers
-	// have been shutdown properly.
-	os.Exit(0)
 }
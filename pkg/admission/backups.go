@@ -137,6 +137,15 @@ func namespaceExists(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, obj a
 			return true
 		}
 	}
+	// a namespace that has been removed from .spec but is still present in
+	// .status is in the process of being removed, and may still be backed up
+	if _, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok {
+		for _, ns := range aerospikeCluster.Status.Namespaces {
+			if ns.Name == obj.GetTarget().Namespace {
+				return true
+			}
+		}
+	}
 	return false
 }
 
@@ -20,13 +20,19 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 
 	av1beta1 "k8s.io/api/admission/v1beta1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/reserved"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/storageclasses"
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
@@ -61,6 +67,40 @@ const (
 	defaultNamespaceReplicationFactor int32 = 2
 )
 
+var (
+	// the patterns that keys in .spec.aerospikeConfig must match. network
+	// keys must be prefixed by the sub-stanza they belong to, and namespace
+	// keys may be prefixed by the storage-engine sub-stanza.
+	serviceConfigKeyPattern   = regexp.MustCompile(`^[a-z0-9-]+$`)
+	networkConfigKeyPattern   = regexp.MustCompile(`^(service|heartbeat|fabric|info)\.[a-z0-9-]+$`)
+	namespaceConfigKeyPattern = regexp.MustCompile(`^(storage-engine\.)?[a-z0-9-]+$`)
+
+	// the keys in .spec.aerospikeConfig that are managed by aerospike-operator
+	reservedServiceConfigKeys = map[string]bool{
+		"node-id": true,
+	}
+	reservedNetworkConfigKeys = map[string]bool{
+		"service.address":                  true,
+		"service.port":                     true,
+		"heartbeat.mode":                   true,
+		"heartbeat.port":                   true,
+		"heartbeat.mesh-seed-address-port": true,
+		"fabric.port":                      true,
+		"info.port":                        true,
+	}
+	reservedNamespaceConfigKeys = map[string]bool{
+		"rack-id":                       true,
+		"replication-factor":            true,
+		"memory-size":                   true,
+		"default-ttl":                   true,
+		"storage-engine":                true,
+		"storage-engine.file":           true,
+		"storage-engine.device":         true,
+		"storage-engine.filesize":       true,
+		"storage-engine.data-in-memory": true,
+	}
+)
+
 func (s *ValidatingAdmissionWebhook) admitAerospikeCluster(ar av1beta1.AdmissionReview) *av1beta1.AdmissionResponse {
 	// decode the new AerospikeCluster object
 	new, err := decodeAerospikeCluster(ar.Request.Object.Raw)
@@ -92,8 +132,14 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 		return fmt.Errorf("the name of the cluster cannot exceed %d characters", AerospikeClusterNameMaxLength)
 	}
 
-	// validate that we can use pod dns names as "mesh-seed-address-port" entries
-	if 2*(len(aerospikeCluster.Name)+2)+len(aerospikeCluster.Namespace) > AerospikeMeshSeedAddressMaxLength {
+	// validate that we can use pod dns names as "mesh-seed-address-port" entries.
+	// pods belonging to node groups may be given two-digit indexes while being
+	// replaced.
+	podIndexMaxLen := 1
+	if len(aerospikeCluster.Spec.NodeGroups) > 0 {
+		podIndexMaxLen = 2
+	}
+	if 2*(len(aerospikeCluster.Name)+1)+podIndexMaxLen+1+len(aerospikeCluster.Namespace) > AerospikeMeshSeedAddressMaxLength {
 		return fmt.Errorf("the current combination of cluster and kubernetes namespace names cannot be used")
 	}
 
@@ -104,9 +150,17 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 		return fmt.Errorf("aerospike version %q is not supported", aerospikeCluster.Spec.Version)
 	}
 
-	// enforce the existence of a single namespace per cluster
-	if len(aerospikeCluster.Spec.Namespaces) != 1 {
-		return fmt.Errorf("the number of namespaces in the cluster must be exactly one")
+	// enforce the existence of at least one namespace per cluster, and that
+	// the number of namespaces does not exceed the limit imposed by aerospike
+	if len(aerospikeCluster.Spec.Namespaces) == 0 {
+		return fmt.Errorf("the cluster must have at least one namespace")
+	}
+	if len(aerospikeCluster.Spec.Namespaces) > MaxNamespaces {
+		return fmt.Errorf("the number of namespaces in the cluster cannot exceed %d", MaxNamespaces)
+	}
+	// prevent two namespaces with the same name from appearing in the spec
+	if len(namespaceMap(aerospikeCluster)) < len(aerospikeCluster.Spec.Namespaces) {
+		return fmt.Errorf("namespace names must be unique")
 	}
 
 	// validate every namespace's name and that its replication factor
@@ -126,6 +180,36 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 		}
 	}
 
+	// validate the user-provided aerospike configuration properties
+	if err := validateAerospikeConfig(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// validate the rack configuration
+	if err := validateRacks(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// validate the rollout timeouts
+	if err := validateRollout(aerospikeCluster.Spec.Rollout); err != nil {
+		return err
+	}
+
+	// validate the node group configuration
+	if err := validateNodeGroups(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// validate the pod template
+	if err := validatePodTemplate(aerospikeCluster.Spec.PodTemplate); err != nil {
+		return err
+	}
+
+	// prevent the metrics exporter from using a port used by aerospike
+	if monitoring := aerospikeCluster.Spec.Monitoring; monitoring != nil && monitoring.Port != nil && reserved.IsPort(*monitoring.Port) {
+		return fmt.Errorf("port %d is reserved and cannot be used by the metrics exporter", *monitoring.Port)
+	}
+
 	// if backupSpec is specified, make sure that the secret containing
 	// cloud storage credentials exists and matches the expected format
 	if aerospikeCluster.Spec.BackupSpec != nil {
@@ -154,6 +238,15 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeClusterUpdate(old, new *ae
 		tmp := new.DeepCopy()
 		// set tmp.Spec.Version to old.Spec.Version
 		tmp.Spec.Version = old.Spec.Version
+		// the digest of the aerospike server image (if any) must change along
+		// with the version, so we disregard it as well
+		oldDigest := aerospikeServerImageDigest(old)
+		if oldDigest != "" && oldDigest == aerospikeServerImageDigest(new) {
+			return fmt.Errorf("when changing .spec.version the digest of the aerospike server image must be changed as well")
+		}
+		if tmp.Spec.Images != nil && tmp.Spec.Images.AerospikeServer != nil {
+			tmp.Spec.Images.AerospikeServer.Digest = oldDigest
+		}
 		// check if old.Spec and tmp.Spec differ
 		// if they do, more than just .spec.Version has been been changed
 		// between old and new, and new must be rejected
@@ -171,13 +264,26 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeClusterUpdate(old, new *ae
 		return err
 	}
 	// validate the namespace configuration
-	if err := validateNamespaces(old, new); err != nil {
+	if err := s.validateNamespaces(old, new); err != nil {
+		return err
+	}
+	// validate the changes to the rack configuration
+	if err := validateRackChanges(old, new); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// aerospikeServerImageDigest returns the digest of the aerospike server image
+// used by the specified cluster, or an empty string if none has been specified.
+func aerospikeServerImageDigest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	if aerospikeCluster.Spec.Images == nil || aerospikeCluster.Spec.Images.AerospikeServer == nil {
+		return ""
+	}
+	return aerospikeCluster.Spec.Images.AerospikeServer.Digest
+}
+
 func validateVersion(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	// if the version was not changed, we're good
 	if old.Spec.Version == new.Spec.Version {
@@ -200,7 +306,7 @@ func validateVersion(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	return nil
 }
 
-func validateNamespaces(old, new *aerospikev1alpha2.AerospikeCluster) error {
+func (s *ValidatingAdmissionWebhook) validateNamespaces(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	// grab a name => spec map for the namespaces in the old object
 	oldnss := namespaceMap(old)
 	// grab a name => spec map for the namespaces in the new object
@@ -209,26 +315,281 @@ func validateNamespaces(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	if len(newnss) < len(new.Spec.Namespaces) {
 		return fmt.Errorf("namespace names must be unique")
 	}
-	// validate that no namespace has been removed
-	for name := range oldnss {
-		if _, ok := newnss[name]; !ok {
-			return fmt.Errorf("cannot remove namespace %s", name)
-		}
-	}
-	// validate that there were no changes to existing namespaces
+	// validate the changes to existing namespaces. namespaces may be added or
+	// removed, and their replication factor may be changed (as long as it
+	// does not exceed the node count, which is validated elsewhere), in which
+	// case the reconciler takes care of rolling the pods.
 	for name := range newnss {
 		// if the namespace didn't exist before, there's nothing to validate
 		if _, ok := oldnss[name]; !ok {
 			continue
 		}
-		// make sure that the replication factor hasn't been changed
-		if oldnss[name].ReplicationFactor != nil && newnss[name].ReplicationFactor != nil && *oldnss[name].ReplicationFactor != *newnss[name].ReplicationFactor {
-			return fmt.Errorf("cannot change the replication factor for namespace %s", name)
-		}
-		// make sure that the storage spec hasn't been changed
-		if !reflect.DeepEqual(oldnss[name].Storage, newnss[name].Storage) {
+		// make sure that the storage spec hasn't been changed, except for an
+		// increase in size or a migration to a different storage type or
+		// storage class
+		oldStorage := oldnss[name].Storage
+		newStorage := newnss[name].Storage
+		tmp := newStorage.DeepCopy()
+		tmp.Type = oldStorage.Type
+		tmp.Size = oldStorage.Size
+		tmp.StorageClassName = oldStorage.StorageClassName
+		if !reflect.DeepEqual(oldStorage, *tmp) {
 			return fmt.Errorf("cannot change the storage spec for namespace %s", name)
 		}
+		if isStorageMigration(&oldStorage, &newStorage) {
+			// the pvcs are replaced, so their size may change freely, but
+			// data is only preserved if it is (and remains) replicated to
+			// other nodes
+			if err := validateStorageMigration(old, oldnss[name]); err != nil {
+				return err
+			}
+			if err := validateStorageMigration(new, newnss[name]); err != nil {
+				return err
+			}
+		} else if oldStorage.Size != newStorage.Size {
+			if err := s.validateStorageExpansion(name, &oldStorage, &newStorage); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isStorageMigration returns whether the change between the specified storage
+// specs requires the namespace's persistent volume claims to be replaced.
+func isStorageMigration(old, new *aerospikev1alpha2.StorageSpec) bool {
+	return old.Type != new.Type || !reflect.DeepEqual(old.StorageClassName, new.StorageClassName)
+}
+
+// validateStorageMigration validates that the data in the specified namespace
+// is replicated to more than one node, as the migration replaces the
+// persistent volume claims of every node one at a time.
+func validateStorageMigration(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespace aerospikev1alpha2.AerospikeNamespaceSpec) error {
+	replicationFactor := defaultNamespaceReplicationFactor
+	if namespace.ReplicationFactor != nil {
+		replicationFactor = *namespace.ReplicationFactor
+	}
+	if replicationFactor < 2 || aerospikeCluster.Spec.NodeCount < 2 {
+		return fmt.Errorf("cannot migrate the storage for namespace %s as its data is not replicated to more than one node", namespace.Name)
+	}
+	return nil
+}
+
+// validateStorageExpansion validates that the storage size of the specified
+// namespace is being increased, and that the storage class in use allows for
+// expanding volumes.
+func (s *ValidatingAdmissionWebhook) validateStorageExpansion(name string, old, new *aerospikev1alpha2.StorageSpec) error {
+	oldSize, err := resource.ParseQuantity(old.Size)
+	if err != nil {
+		return err
+	}
+	newSize, err := resource.ParseQuantity(new.Size)
+	if err != nil {
+		return err
+	}
+	if newSize.Cmp(oldSize) < 0 {
+		return fmt.Errorf("cannot decrease the storage size for namespace %s", name)
+	}
+	sc, err := s.getStorageClass(new.StorageClassName)
+	if err != nil {
+		return err
+	}
+	if sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion {
+		return fmt.Errorf("cannot increase the storage size for namespace %s as storage class %q does not allow volume expansion", name, sc.Name)
+	}
+	return nil
+}
+
+// getStorageClass returns the storage class with the specified name, or the
+// default storage class if name is not specified.
+func (s *ValidatingAdmissionWebhook) getStorageClass(name *string) (*storagev1.StorageClass, error) {
+	if name != nil && *name != "" {
+		sc, err := s.kubeClient.StorageV1().StorageClasses().Get(context.TODO(), *name, v1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("storage class %q does not exist", *name)
+			}
+			return nil, err
+		}
+		return sc, nil
+	}
+	scs, err := s.kubeClient.StorageV1().StorageClasses().List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range scs.Items {
+		if storageclasses.IsDefault(&sc) {
+			return &sc, nil
+		}
+	}
+	return nil, fmt.Errorf("no default storage class found")
+}
+
+func validateAerospikeConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	type stanza struct {
+		path     string
+		props    map[string]string
+		pattern  *regexp.Regexp
+		reserved map[string]bool
+	}
+	var stanzas []stanza
+	if aerospikeConfig := aerospikeCluster.Spec.AerospikeConfig; aerospikeConfig != nil {
+		stanzas = append(stanzas,
+			stanza{".spec.aerospikeConfig.service", aerospikeConfig.Service, serviceConfigKeyPattern, reservedServiceConfigKeys},
+			stanza{".spec.aerospikeConfig.network", aerospikeConfig.Network, networkConfigKeyPattern, reservedNetworkConfigKeys},
+			stanza{".spec.aerospikeConfig.namespace", aerospikeConfig.Namespace, namespaceConfigKeyPattern, reservedNamespaceConfigKeys},
+		)
+	}
+	for i, ns := range aerospikeCluster.Spec.Namespaces {
+		stanzas = append(stanzas, stanza{fmt.Sprintf(".spec.namespaces[%d].aerospikeConfig", i), ns.AerospikeConfig, namespaceConfigKeyPattern, reservedNamespaceConfigKeys})
+	}
+	for _, stanza := range stanzas {
+		for key, value := range stanza.props {
+			// make sure that the key is valid for the stanza
+			if !stanza.pattern.MatchString(key) {
+				return fmt.Errorf("invalid key %q in %s", key, stanza.path)
+			}
+			// prevent the user from setting properties managed by aerospike-operator
+			if stanza.reserved[key] {
+				return fmt.Errorf("key %q in %s is managed by aerospike-operator and cannot be set", key, stanza.path)
+			}
+			// prevent values from breaking the structure of aerospike.conf
+			if strings.TrimSpace(value) == "" || strings.ContainsAny(value, "{}#\r\n") {
+				return fmt.Errorf("invalid value %q for key %q in %s", value, key, stanza.path)
+			}
+		}
+	}
+	return nil
+}
+
+func validateRollout(rollout *aerospikev1alpha2.RolloutSpec) error {
+	if rollout == nil {
+		return nil
+	}
+	if rollout.StabilizationTimeout != nil && rollout.StabilizationTimeout.Duration <= 0 {
+		return fmt.Errorf("the stabilization timeout must be greater than zero")
+	}
+	if rollout.QuiesceTimeout != nil && rollout.QuiesceTimeout.Duration <= 0 {
+		return fmt.Errorf("the quiesce timeout must be greater than zero")
+	}
+	return nil
+}
+
+func validateNodeGroups(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	if len(aerospikeCluster.Spec.NodeGroups) == 0 {
+		return nil
+	}
+	// prevent two node groups with the same name from appearing in the spec,
+	// and make sure that the node counts add up to the cluster's node count
+	names := make(map[string]bool, len(aerospikeCluster.Spec.NodeGroups))
+	var nodeCount int32
+	for _, nodeGroup := range aerospikeCluster.Spec.NodeGroups {
+		if names[nodeGroup.Name] {
+			return fmt.Errorf("node group names must be unique")
+		}
+		names[nodeGroup.Name] = true
+		nodeCount += nodeGroup.NodeCount
+	}
+	if nodeCount != aerospikeCluster.Spec.NodeCount {
+		return fmt.Errorf("the node counts of the node groups add up to %d but the cluster has %d nodes", nodeCount, aerospikeCluster.Spec.NodeCount)
+	}
+	return nil
+}
+
+func validateRacks(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	if len(aerospikeCluster.Spec.Racks) == 0 {
+		return nil
+	}
+	// prevent two racks with the same id from appearing in the spec
+	ids := make(map[int32]bool, len(aerospikeCluster.Spec.Racks))
+	for _, rack := range aerospikeCluster.Spec.Racks {
+		if ids[rack.ID] {
+			return fmt.Errorf("rack ids must be unique")
+		}
+		ids[rack.ID] = true
+	}
+	// make sure that every rack is allocated at least one node
+	allocated := make(map[int32]bool, len(aerospikeCluster.Spec.Racks))
+	for i := 0; i < int(aerospikeCluster.Spec.NodeCount); i++ {
+		allocated[aerospikeCluster.Spec.GetRackForPodIndex(i).ID] = true
+	}
+	if len(allocated) < len(aerospikeCluster.Spec.Racks) {
+		return fmt.Errorf("a node count of %d is not enough to allocate at least one node to each rack", aerospikeCluster.Spec.NodeCount)
+	}
+	return nil
+}
+
+// validateRackChanges validates that existing racks are neither removed nor
+// moved to a different failure domain. racks may be added and their weights
+// changed, in which case the reconciler moves pods to their new racks one at a
+// time.
+func validateRackChanges(old, new *aerospikev1alpha2.AerospikeCluster) error {
+	newRacks := make(map[int32]*aerospikev1alpha2.RackSpec, len(new.Spec.Racks))
+	for i := range new.Spec.Racks {
+		newRacks[new.Spec.Racks[i].ID] = &new.Spec.Racks[i]
+	}
+	for i := range old.Spec.Racks {
+		oldRack := &old.Spec.Racks[i]
+		newRack, ok := newRacks[oldRack.ID]
+		if !ok {
+			return fmt.Errorf("rack %d cannot be removed from an existing cluster", oldRack.ID)
+		}
+		if !oldRack.IsInSameFailureDomainAs(newRack) {
+			return fmt.Errorf("the zone and node selector of rack %d cannot be changed", oldRack.ID)
+		}
+	}
+	// pods moved to a rack in a different failure domain are given new
+	// persistent volume claims, so data is only preserved if it is replicated
+	// to other nodes
+	nodeCount := old.Spec.NodeCount
+	if new.Spec.NodeCount < nodeCount {
+		nodeCount = new.Spec.NodeCount
+	}
+	for i := 0; i < int(nodeCount); i++ {
+		if old.Spec.GetRackForPodIndex(i).IsInSameFailureDomainAs(new.Spec.GetRackForPodIndex(i)) {
+			continue
+		}
+		for _, namespace := range new.Spec.Namespaces {
+			replicationFactor := defaultNamespaceReplicationFactor
+			if namespace.ReplicationFactor != nil {
+				replicationFactor = *namespace.ReplicationFactor
+			}
+			if replicationFactor < 2 || new.Spec.NodeCount < 2 {
+				return fmt.Errorf("cannot move nodes to a different rack as the data in namespace %s is not replicated to more than one node", namespace.Name)
+			}
+		}
+		break
+	}
+	return nil
+}
+
+func validatePodTemplate(podTemplate *aerospikev1alpha2.PodTemplateSpec) error {
+	if podTemplate == nil {
+		return nil
+	}
+	// prevent clashes with the containers managed by aerospike-operator, as
+	// well as between user-provided containers
+	containerNames := make(map[string]bool, len(podTemplate.InitContainers)+len(podTemplate.Containers))
+	for _, container := range append(podTemplate.InitContainers, podTemplate.Containers...) {
+		if reserved.IsContainerName(container.Name) {
+			return fmt.Errorf("container name %q is reserved", container.Name)
+		}
+		if containerNames[container.Name] {
+			return fmt.Errorf("container names must be unique")
+		}
+		containerNames[container.Name] = true
+	}
+	// prevent clashes with the volumes managed by aerospike-operator, as well
+	// as between user-provided volumes
+	volumeNames := make(map[string]bool, len(podTemplate.Volumes))
+	for _, volume := range podTemplate.Volumes {
+		if reserved.IsVolumeName(volume.Name) {
+			return fmt.Errorf("volume name %q is reserved", volume.Name)
+		}
+		if volumeNames[volume.Name] {
+			return fmt.Errorf("volume names must be unique")
+		}
+		volumeNames[volume.Name] = true
 	}
 	return nil
 }
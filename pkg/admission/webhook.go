@@ -55,6 +55,13 @@ import (
 var (
 	// Enabled represents whether the validating admission webhook is enabled.
 	Enabled bool
+	// MaxNamespaces represents the maximum number of namespaces an AerospikeCluster may have. It defaults to the
+	// maximum number of namespaces supported by Aerospike Community Edition, as described in
+	//
+	// https://www.aerospike.com/products/product-matrix/
+	//
+	// and may be raised for clusters running Aerospike Enterprise Edition.
+	MaxNamespaces = 2
 )
 
 var (
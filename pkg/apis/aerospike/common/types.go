@@ -16,10 +16,6 @@ limitations under the License.
 
 package common
 
-import (
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
-)
-
 const (
 	// StorageTypeFile defines the file storage type for a given Aerospike namespace.
 	StorageTypeFile = "file"
@@ -30,47 +26,81 @@ const (
 	// StorageTypeGCS defines the Google Cloud Storage type for a given Aerospike backup.
 	StorageTypeGCS = "gcs"
 
+	// PodAntiAffinityHard defines that Aerospike pods must not be scheduled on the same Kubernetes node.
+	PodAntiAffinityHard = "hard"
+
+	// PodAntiAffinitySoft defines that Aerospike pods should preferably not be scheduled on the same Kubernetes node.
+	PodAntiAffinitySoft = "soft"
+
+	// ConditionReady defines a status condition that indicates that every Aerospike node in a
+	// cluster is running and ready
+	ConditionReady = "Ready"
+
+	// ConditionProgressing defines a status condition that indicates that an operation (such as
+	// scaling, a rolling restart or an upgrade) is being performed on a cluster
+	ConditionProgressing = "Progressing"
+
+	// ConditionDegraded defines a status condition that indicates that the last attempt at
+	// reconciling a cluster has failed
+	ConditionDegraded = "Degraded"
+
+	// ConditionValid defines a status condition that indicates whether the spec of a cluster is
+	// valid according to the checks that cannot be performed by the admission webhook
+	ConditionValid = "Valid"
+
+	// ConditionPaused defines a status condition that indicates that reconciliation of a cluster
+	// is paused
+	ConditionPaused = "Paused"
+
 	// ConditionBackupFailed defines a status condition that indicates that a backup job has failed
-	ConditionBackupFailed apiextensions.CustomResourceDefinitionConditionType = "BackupFailed"
+	ConditionBackupFailed = "BackupFailed"
 
 	// ConditionBackupFinished defines a status condition that indicates that a backup job has finished
-	ConditionBackupFinished apiextensions.CustomResourceDefinitionConditionType = "BackupFinished"
+	ConditionBackupFinished = "BackupFinished"
 
 	// ConditionBackupStarted defines a status condition that indicates that a backup job has started
-	ConditionBackupStarted apiextensions.CustomResourceDefinitionConditionType = "BackupStarted"
+	ConditionBackupStarted = "BackupStarted"
 
 	// ConditionRestoreFailed defines a status condition that indicates that a restore job has failed
-	ConditionRestoreFailed apiextensions.CustomResourceDefinitionConditionType = "RestoreFailed"
+	ConditionRestoreFailed = "RestoreFailed"
 
 	// ConditionRestoreFinished defines a status condition that indicates that a restore job has finished
-	ConditionRestoreFinished apiextensions.CustomResourceDefinitionConditionType = "RestoreFinished"
+	ConditionRestoreFinished = "RestoreFinished"
 
 	// ConditionRestoreStarted defines a status condition that indicates that a restore job has started
-	ConditionRestoreStarted apiextensions.CustomResourceDefinitionConditionType = "RestoreStarted"
+	ConditionRestoreStarted = "RestoreStarted"
 
 	// ConditionUpgradeStarted defines a status condition that indicates that an upgrade to an
 	// Aerospike cluster has started
-	ConditionUpgradeStarted apiextensions.CustomResourceDefinitionConditionType = "UpgradeStarted"
+	ConditionUpgradeStarted = "UpgradeStarted"
 
 	// ConditionUpgradeFinished defines a status condition that indicates that an upgrade to an
 	// Aerospike cluster has finished
-	ConditionUpgradeFinished apiextensions.CustomResourceDefinitionConditionType = "UpgradeFinished"
+	ConditionUpgradeFinished = "UpgradeFinished"
 
 	// ConditionUpgradeFailed defines a status condition that indicates that an upgrade to an
 	// Aerospike cluster has failed
-	ConditionUpgradeFailed apiextensions.CustomResourceDefinitionConditionType = "UpgradeFailed"
+	ConditionUpgradeFailed = "UpgradeFailed"
 
 	// ConditionAutoBackupStarted defines a status condition that indicates that a pre-upgrade
 	// backup for an Aerospike cluster has started
-	ConditionAutoBackupStarted apiextensions.CustomResourceDefinitionConditionType = "AutoBackupStarted"
+	ConditionAutoBackupStarted = "AutoBackupStarted"
 
 	// ConditionAutoBackupFinished defines a status condition that indicates that a pre-upgrade
 	// backup for an Aerospike cluster has finished
-	ConditionAutoBackupFinished apiextensions.CustomResourceDefinitionConditionType = "AutoBackupFinished"
+	ConditionAutoBackupFinished = "AutoBackupFinished"
 
 	// ConditionAutoBackupFailed defines a status condition that indicates that a pre-upgrade
 	// backup for an Aerospike cluster has failed
-	ConditionAutoBackupFailed apiextensions.CustomResourceDefinitionConditionType = "AutoBackupFailed"
+	ConditionAutoBackupFailed = "AutoBackupFailed"
+
+	// ConditionReplicationFactorChangeStarted defines a status condition that indicates that a change
+	// to the replication factor of an Aerospike namespace has started
+	ConditionReplicationFactorChangeStarted = "ReplicationFactorChangeStarted"
+
+	// ConditionReplicationFactorChangeFinished defines a status condition that indicates that a change
+	// to the replication factor of an Aerospike namespace has finished
+	ConditionReplicationFactorChangeFinished = "ReplicationFactorChangeFinished"
 
 	// DefaultSecretFilename represents the name of the file that is required to exist
 	// in the secret referenced in BackupStorageSpec objects.
@@ -89,3 +119,57 @@ const (
 	AerospikeNamespaceBackupKind  = "AerospikeNamespaceBackup"
 	AerospikeNamespaceRestoreKind = "AerospikeNamespaceRestore"
 )
+
+// PodOperationType represents the type of an operation being performed on a
+// single pod of an Aerospike cluster.
+type PodOperationType string
+
+const (
+	// PodOperationTypeCreate indicates that a missing pod is being created.
+	PodOperationTypeCreate PodOperationType = "create"
+	// PodOperationTypeRestart indicates that a pod is being deleted and re-created.
+	PodOperationTypeRestart PodOperationType = "restart"
+	// PodOperationTypeUpgrade indicates that a pod is being re-created using a different version of Aerospike.
+	PodOperationTypeUpgrade PodOperationType = "upgrade"
+	// PodOperationTypeMigrateStorage indicates that a pod is being re-created with new persistent volume claims.
+	PodOperationTypeMigrateStorage PodOperationType = "migrateStorage"
+	// PodOperationTypeReplace indicates that a pod is being re-created with new persistent volume claims at the user's request.
+	PodOperationTypeReplace PodOperationType = "replace"
+	// PodOperationTypeDelete indicates that a pod is being removed from the Aerospike cluster.
+	PodOperationTypeDelete PodOperationType = "delete"
+)
+
+// PodOperationStep represents the step of an operation being performed on a
+// single pod of an Aerospike cluster.
+type PodOperationStep string
+
+const (
+	// PodOperationStepWaitingForMigrations indicates that migrations involving the pod must finish before it is deleted.
+	PodOperationStepWaitingForMigrations PodOperationStep = "WaitingForMigrations"
+	// PodOperationStepQuiescing indicates that the pod's Aerospike node must hand off its master partitions before the pod is deleted.
+	PodOperationStepQuiescing PodOperationStep = "Quiescing"
+	// PodOperationStepDeleting indicates that the pod has been deleted and is terminating.
+	PodOperationStepDeleting PodOperationStep = "Deleting"
+	// PodOperationStepCreating indicates that the pod has been created and is starting.
+	PodOperationStepCreating PodOperationStep = "Creating"
+	// PodOperationStepSettling indicates that the pod must join the cluster and migrations involving it must finish.
+	PodOperationStepSettling PodOperationStep = "Settling"
+)
+
+// AerospikeClusterPhase represents the phase an Aerospike cluster is in.
+type AerospikeClusterPhase string
+
+const (
+	// AerospikeClusterPhaseCreating indicates that the Aerospike cluster is being created.
+	AerospikeClusterPhaseCreating AerospikeClusterPhase = "Creating"
+	// AerospikeClusterPhaseRunning indicates that the Aerospike cluster is running and no operation is in progress.
+	AerospikeClusterPhaseRunning AerospikeClusterPhase = "Running"
+	// AerospikeClusterPhaseScaling indicates that pods are being added to or removed from the Aerospike cluster.
+	AerospikeClusterPhaseScaling AerospikeClusterPhase = "Scaling"
+	// AerospikeClusterPhaseRestarting indicates that the pods of the Aerospike cluster are being restarted.
+	AerospikeClusterPhaseRestarting AerospikeClusterPhase = "Restarting"
+	// AerospikeClusterPhaseUpgrading indicates that the Aerospike cluster is being upgraded.
+	AerospikeClusterPhaseUpgrading AerospikeClusterPhase = "Upgrading"
+	// AerospikeClusterPhaseFailed indicates that an upgrade of the Aerospike cluster has failed.
+	AerospikeClusterPhaseFailed AerospikeClusterPhase = "Failed"
+)
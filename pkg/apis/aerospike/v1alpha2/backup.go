@@ -19,7 +19,6 @@ package v1alpha2
 import (
 	"reflect"
 
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
@@ -105,8 +104,8 @@ type AerospikeNamespaceBackupStatus struct {
 	// The configuration for the backup operation.
 	AerospikeNamespaceBackupSpec
 	// Details about the current condition of the AerospikeNamespaceBackup resource.
-	// +k8s:openapi-gen=false
-	Conditions []apiextensions.CustomResourceDefinitionCondition `json="conditions"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -153,23 +152,23 @@ func (b *AerospikeNamespaceBackup) GetTarget() *TargetNamespace {
 	return &b.Spec.Target
 }
 
-func (b *AerospikeNamespaceBackup) GetConditions() []apiextensions.CustomResourceDefinitionCondition {
+func (b *AerospikeNamespaceBackup) GetConditions() []metav1.Condition {
 	return b.Status.Conditions
 }
 
-func (b *AerospikeNamespaceBackup) SetConditions(newConditions []apiextensions.CustomResourceDefinitionCondition) {
+func (b *AerospikeNamespaceBackup) SetConditions(newConditions []metav1.Condition) {
 	b.Status.Conditions = newConditions
 }
 
-func (b *AerospikeNamespaceBackup) GetFailedConditionType() apiextensions.CustomResourceDefinitionConditionType {
+func (b *AerospikeNamespaceBackup) GetFailedConditionType() string {
 	return common.ConditionBackupFailed
 }
 
-func (b *AerospikeNamespaceBackup) GetFinishedConditionType() apiextensions.CustomResourceDefinitionConditionType {
+func (b *AerospikeNamespaceBackup) GetFinishedConditionType() string {
 	return common.ConditionBackupFinished
 }
 
-func (b *AerospikeNamespaceBackup) GetStartedConditionType() apiextensions.CustomResourceDefinitionConditionType {
+func (b *AerospikeNamespaceBackup) GetStartedConditionType() string {
 	return common.ConditionBackupStarted
 }
 
@@ -18,8 +18,9 @@ package v1alpha2
 
 import (
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 )
 
 // +genclient
@@ -45,7 +46,7 @@ type AerospikeClusterSpec struct {
 	// The version of Aerospike to be deployed.
 	Version string `json:"version"`
 	// The specification of the Aerospike namespaces in the cluster.
-	// Must have exactly one element.
+	// Must have at least one element, and at most two unless configured otherwise.
 	Namespaces []AerospikeNamespaceSpec `json:"namespaces"`
 	// The specification of how Aerospike namespace backups made by aerospike-operator should be performed and stored.
 	// It is only required to be present if one wants to perform version upgrades on the Aerospike cluster.
@@ -57,6 +58,40 @@ type AerospikeClusterSpec struct {
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 	// If specified, the pod's tolerations.
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Additional Aerospike configuration properties to be merged into the generated aerospike.conf file.
+	// +optional
+	AerospikeConfig *AerospikeConfigSpec `json:"aerospikeConfig,omitempty"`
+	// The specification of the groups of nodes in the Aerospike cluster, each with its own resources and scheduling
+	// constraints. If present, the sum of the node counts of every group must equal nodeCount.
+	// +optional
+	NodeGroups []NodeGroupSpec `json:"nodeGroups,omitempty"`
+	// The specification of the racks among which the Aerospike nodes are spread.
+	// Replicas of a given record are kept in different racks whenever possible.
+	// Racks can be added and their weights changed on an existing cluster, but existing racks cannot be removed and
+	// their zone and node selector cannot be changed.
+	// +optional
+	Racks []RackSpec `json:"racks,omitempty"`
+	// Additional settings to be merged into the pods created for the Aerospike cluster.
+	// +optional
+	PodTemplate *PodTemplateSpec `json:"podTemplate,omitempty"`
+	// The container images used by the pods and jobs of the Aerospike cluster.
+	// +optional
+	Images *ImagesSpec `json:"images,omitempty"`
+	// The specification of the metrics exporter running alongside each Aerospike node.
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+	// Whether reconciliation of the Aerospike cluster is paused. While paused, aerospike-operator makes no changes to
+	// the pods and configuration of the Aerospike cluster, but keeps reporting its status. An Aerospike node being
+	// quiesced remains out of service until reconciliation is resumed.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// Changing this value causes every pod in the Aerospike cluster to be restarted, one at a time.
+	// +optional
+	RestartGeneration int64 `json:"restartGeneration,omitempty"`
+	// The timeouts used while operating on the pods of the Aerospike cluster one at a time (e.g. during rolling
+	// restarts, upgrades and scale-down operations).
+	// +optional
+	Rollout *RolloutSpec `json:"rollout,omitempty"`
 }
 
 // AerospikeClusterStatus represents the current state of an Aerospike cluster.
@@ -64,8 +99,69 @@ type AerospikeClusterStatus struct {
 	// The desired state of the Aerospike cluster.
 	AerospikeClusterSpec
 	// Details about the current condition of the AerospikeCluster resource.
-	// +k8s:openapi-gen=false
-	Conditions []apiextensions.CustomResourceDefinitionCondition `json:"conditions"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// The operation currently being performed on a pod of the Aerospike cluster, if any.
+	// +optional
+	PodOperation *PodOperation `json:"podOperation,omitempty"`
+	// The generation of the AerospikeCluster resource that was last reconciled successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The phase the Aerospike cluster is in.
+	// +optional
+	Phase common.AerospikeClusterPhase `json:"phase,omitempty"`
+	// A human-readable description of the operation currently being performed on the Aerospike cluster and of its
+	// progress (e.g. "upgrading 3/6"), if any.
+	// +optional
+	Operation string `json:"operation,omitempty"`
+	// The cluster key shared by the Aerospike nodes, if they all agree on it.
+	// +optional
+	ClusterKey string `json:"clusterKey,omitempty"`
+	// The observed state of each Aerospike node.
+	// +optional
+	Nodes []AerospikeNodeStatus `json:"nodes,omitempty"`
+}
+
+// AerospikeNodeStatus represents the observed state of an Aerospike node.
+type AerospikeNodeStatus struct {
+	// The name of the pod running the Aerospike node.
+	PodName string `json:"podName"`
+	// The ID of the Aerospike node.
+	// +optional
+	NodeID string `json:"nodeId,omitempty"`
+	// The IP address of the pod running the Aerospike node.
+	// +optional
+	PodIP string `json:"podIP,omitempty"`
+	// The version of Aerospike reported by the Aerospike node.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Whether the pod running the Aerospike node is running and ready.
+	Ready bool `json:"ready"`
+	// The size of the cluster as seen by the Aerospike node.
+	// +optional
+	ClusterSize int `json:"clusterSize,omitempty"`
+	// The number of partitions the Aerospike node has left to migrate.
+	// +optional
+	MigrationsRemaining int64 `json:"migrationsRemaining,omitempty"`
+}
+
+// PodOperation describes an operation being performed on a single pod of an Aerospike cluster. It is recorded in the
+// status of the AerospikeCluster resource so that the operation can be resumed across reconciles.
+type PodOperation struct {
+	// The type of the operation.
+	Type common.PodOperationType `json:"type"`
+	// The index of the pod being operated on.
+	PodIndex int `json:"podIndex"`
+	// The name of the node group to which the pod belongs, if any.
+	// +optional
+	NodeGroup string `json:"nodeGroup,omitempty"`
+	// The step of the operation currently being performed.
+	Step common.PodOperationStep `json:"step"`
+	// The time at which the current step was started.
+	StepStartTime metav1.Time `json:"stepStartTime"`
+	// The number of times the Aerospike node running on the pod failed to hand off its master partitions in time.
+	// +optional
+	QuiesceFailures int `json:"quiesceFailures,omitempty"`
 }
 
 // AerospikeNamespaceSpec specifies the configuration for an Aerospike namespace.
@@ -88,6 +184,242 @@ type AerospikeNamespaceSpec struct {
 	DefaultTTL *string `json:"defaultTTL,omitempty"`
 	// Specifies how data for the Aerospike namespace will be stored.
 	Storage StorageSpec `json:"storage"`
+	// Properties to be set in the stanza of this Aerospike namespace, which take precedence over the ones in
+	// .spec.aerospikeConfig.namespace. Properties belonging to the storage-engine sub-stanza must be prefixed with
+	// storage-engine (e.g., storage-engine.write-block-size).
+	// +optional
+	AerospikeConfig map[string]string `json:"aerospikeConfig,omitempty"`
+}
+
+// AerospikeConfigSpec specifies additional Aerospike configuration properties to be merged into the aerospike.conf file
+// generated by aerospike-operator. Properties managed by aerospike-operator (such as ports, node-id or mesh seeds) cannot be set.
+type AerospikeConfigSpec struct {
+	// Properties to be set in the service stanza.
+	// +optional
+	Service map[string]string `json:"service,omitempty"`
+	// Properties to be set in the network stanza, prefixed with the name of the sub-stanza they belong to
+	// (e.g., heartbeat.interval).
+	// +optional
+	Network map[string]string `json:"network,omitempty"`
+	// Properties to be set in the stanza of every Aerospike namespace. Properties belonging to the storage-engine
+	// sub-stanza must be prefixed with storage-engine (e.g., storage-engine.write-block-size). Properties can be
+	// overridden for a single Aerospike namespace using its aerospikeConfig field.
+	// +optional
+	Namespace map[string]string `json:"namespace,omitempty"`
+}
+
+// PodTemplateSpec specifies additional settings to be merged into the pods created for an Aerospike cluster.
+type PodTemplateSpec struct {
+	// Additional labels to be added to every pod. Labels managed by aerospike-operator take precedence.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Additional annotations to be added to every pod. Annotations managed by aerospike-operator take precedence.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// The name of the priority class of the pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// The name of the service account used to run the pods.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// The pod-level security attributes of the pods.
+	// +optional
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+	// Describes how the pods ought to spread across topology domains.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// Whether the pods must (hard) or should preferably (soft) not be scheduled on the same Kubernetes node.
+	// Defaults to hard.
+	// +optional
+	AntiAffinity string `json:"antiAffinity,omitempty"`
+	// Additional volumes to be added to every pod.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// Additional init containers to be run after the init container managed by aerospike-operator.
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+	// Additional (sidecar) containers to be added to every pod.
+	// +optional
+	Containers []corev1.Container `json:"containers,omitempty"`
+}
+
+// ImagesSpec specifies the container images used by the pods and jobs of an Aerospike cluster.
+type ImagesSpec struct {
+	// The image used by the Aerospike Server container.
+	// +optional
+	AerospikeServer *ImageSpec `json:"aerospikeServer,omitempty"`
+	// The image used by the init, asprom and backup/restore containers.
+	// +optional
+	Tools *ImageSpec `json:"tools,omitempty"`
+	// The pull policy for every image.
+	// +optional
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+	// The secrets used to pull the images.
+	// +optional
+	PullSecrets []corev1.LocalObjectReference `json:"pullSecrets,omitempty"`
+}
+
+// ImageSpec specifies a container image.
+type ImageSpec struct {
+	// The repository from which to pull the image. The tag always matches the
+	// corresponding version.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	// The digest of the image (e.g. "sha256:..."), which takes precedence over
+	// the tag.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+// MonitoringSpec specifies the metrics exporter running alongside each Aerospike node.
+type MonitoringSpec struct {
+	// Whether to run the metrics exporter. Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// The image of the metrics exporter. Defaults to the aerospike-operator-tools image, which runs asprom.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// The port on which the metrics exporter serves metrics. Defaults to 9145.
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+	// Additional arguments for the metrics exporter.
+	// +optional
+	Args []string `json:"args,omitempty"`
+	// Standard requests and limits for the metrics exporter container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// The specification of the Prometheus Operator objects created for the Aerospike cluster.
+	// +optional
+	PrometheusOperator *PrometheusOperatorSpec `json:"prometheusOperator,omitempty"`
+}
+
+// PrometheusOperatorSpec specifies the PodMonitor and PrometheusRule objects created for an Aerospike cluster.
+type PrometheusOperatorSpec struct {
+	// Whether to create a PodMonitor (and a PrometheusRule) for the Aerospike cluster. Requires the
+	// monitoring.coreos.com CRDs to be installed.
+	Enabled bool `json:"enabled"`
+	// Additional labels for the PodMonitor and PrometheusRule (e.g. so that they are selected by Prometheus).
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// The interval at which metrics are scraped (e.g. "30s"). Defaults to the scrape interval of Prometheus.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// Whether to create a PrometheusRule with the default alerts. Defaults to true.
+	// +optional
+	Alerts *bool `json:"alerts,omitempty"`
+}
+
+// RolloutSpec specifies the timeouts used while operating on the pods of an Aerospike cluster one at a time.
+type RolloutSpec struct {
+	// How long to wait for the Aerospike cluster to become stable (i.e. for every Aerospike node to agree on the
+	// cluster and for migrations to finish) before deleting a pod and after re-creating it (e.g. "30m"). Must be
+	// greater than zero. Defaults to "1h".
+	// +optional
+	StabilizationTimeout *metav1.Duration `json:"stabilizationTimeout,omitempty"`
+	// How long to wait for a quiesced Aerospike node to hand off its master partitions before putting it back into
+	// service and backing off (e.g. "30m"). Must be greater than zero. Defaults to "1h".
+	// +optional
+	QuiesceTimeout *metav1.Duration `json:"quiesceTimeout,omitempty"`
+}
+
+// NodeGroupSpec specifies a group of Aerospike nodes sharing the same resources and scheduling constraints.
+type NodeGroupSpec struct {
+	// The name of the node group. Must be unique within the cluster.
+	Name string `json:"name"`
+	// The number of nodes in the node group.
+	NodeCount int32 `json:"nodeCount"`
+	// Define resources requests and limits for the Aerospike Server Container of the pods in the node group.
+	// Defaults to .spec.resources.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Define which Nodes the Pods in the node group are scheduled on. Defaults to .spec.nodeSelector.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// If specified, the tolerations of the pods in the node group. Defaults to .spec.tolerations.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// RackSpec specifies a rack, i.e. a group of Aerospike nodes that share a failure domain (such as an availability zone).
+type RackSpec struct {
+	// The rack-id of the Aerospike nodes belonging to the rack. Must be unique within the cluster.
+	ID int32 `json:"id"`
+	// The availability zone in which to schedule the pods belonging to the rack.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// Define which Nodes the Pods belonging to the rack are scheduled on, in addition to .spec.nodeSelector.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// The share of .spec.nodeCount allocated to the rack, relative to the weights of the remaining racks.
+	// Defaults to 1, meaning that nodes are spread evenly across racks.
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+// GetWeight returns the share of .spec.nodeCount allocated to the rack.
+func (r *RackSpec) GetWeight() int32 {
+	if r.Weight == nil {
+		return 1
+	}
+	return *r.Weight
+}
+
+// IsInSameFailureDomainAs returns whether the pods belonging to the rack are scheduled using the same zone and node
+// selector as the pods belonging to other. A nil rack stands for the absence of racks.
+func (r *RackSpec) IsInSameFailureDomainAs(other *RackSpec) bool {
+	var zone, otherZone string
+	var nodeSelector, otherNodeSelector map[string]string
+	if r != nil {
+		zone, nodeSelector = r.Zone, r.NodeSelector
+	}
+	if other != nil {
+		otherZone, otherNodeSelector = other.Zone, other.NodeSelector
+	}
+	if zone != otherZone || len(nodeSelector) != len(otherNodeSelector) {
+		return false
+	}
+	for key, value := range nodeSelector {
+		if otherValue, ok := otherNodeSelector[key]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRackForPodIndex returns the rack to which the pod with the specified index belongs, or nil if no racks have been
+// defined. Pods are allocated to racks using smooth weighted round-robin, so that every rack gets its share of pods
+// regardless of the number of nodes in the cluster and so that a given index always maps to the same rack.
+func (s *AerospikeClusterSpec) GetRackForPodIndex(index int) *RackSpec {
+	if len(s.Racks) == 0 {
+		return nil
+	}
+	var total int32
+	for i := range s.Racks {
+		total += s.Racks[i].GetWeight()
+	}
+	current := make([]int32, len(s.Racks))
+	var res int
+	for i := 0; i <= index; i++ {
+		res = 0
+		for j := range s.Racks {
+			current[j] += s.Racks[j].GetWeight()
+			if current[j] > current[res] {
+				res = j
+			}
+		}
+		current[res] -= total
+	}
+	return &s.Racks[res]
+}
+
+// GetNodeGroup returns the node group with the specified name, or nil if no such node group exists.
+func (s *AerospikeClusterSpec) GetNodeGroup(name string) *NodeGroupSpec {
+	for i := range s.NodeGroups {
+		if s.NodeGroups[i].Name == name {
+			return &s.NodeGroups[i]
+		}
+	}
+	return nil
 }
 
 // AerospikeClusterBackupSpec specifies how Aerospike namespace backups made by aerospike-operator before a version upgrade should be stored.
@@ -98,6 +430,19 @@ type AerospikeClusterBackupSpec struct {
 	TTL *string `json:"ttl,omitempty"`
 	// Specifies how the backup should be stored.
 	Storage BackupStorageSpec `json:"storage"`
+	// Whether to make a final backup of an Aerospike namespace before removing it from the Aerospike cluster.
+	// Defaults to true.
+	// +optional
+	BackupRemovedNamespaces *bool `json:"backupRemovedNamespaces,omitempty"`
+}
+
+// ShouldBackupRemovedNamespaces returns whether a final backup of an Aerospike namespace must be made before removing it
+// from the Aerospike cluster.
+func (s *AerospikeClusterBackupSpec) ShouldBackupRemovedNamespaces() bool {
+	if s == nil {
+		return false
+	}
+	return s.BackupRemovedNamespaces == nil || *s.BackupRemovedNamespaces
 }
 
 // StorageSpec specifies how data in a given Aerospike namespace will be stored.
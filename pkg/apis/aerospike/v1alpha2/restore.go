@@ -19,7 +19,6 @@ package v1alpha2
 import (
 	"reflect"
 
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
@@ -55,8 +54,8 @@ type AerospikeNamespaceRestoreStatus struct {
 	// The configuration for the restore operation.
 	AerospikeNamespaceRestoreSpec
 	// Details about the current condition of the AerospikeNamespaceRestore resource.
-	// +k8s:openapi-gen=false
-	Conditions []apiextensions.CustomResourceDefinitionCondition `json="conditions"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -103,23 +102,23 @@ func (r *AerospikeNamespaceRestore) GetTarget() *TargetNamespace {
 	return &r.Spec.Target
 }
 
-func (r *AerospikeNamespaceRestore) GetConditions() []apiextensions.CustomResourceDefinitionCondition {
+func (r *AerospikeNamespaceRestore) GetConditions() []metav1.Condition {
 	return r.Status.Conditions
 }
 
-func (r *AerospikeNamespaceRestore) SetConditions(newConditions []apiextensions.CustomResourceDefinitionCondition) {
+func (r *AerospikeNamespaceRestore) SetConditions(newConditions []metav1.Condition) {
 	r.Status.Conditions = newConditions
 }
 
-func (b *AerospikeNamespaceRestore) GetFailedConditionType() apiextensions.CustomResourceDefinitionConditionType {
+func (b *AerospikeNamespaceRestore) GetFailedConditionType() string {
 	return common.ConditionRestoreFailed
 }
 
-func (b *AerospikeNamespaceRestore) GetFinishedConditionType() apiextensions.CustomResourceDefinitionConditionType {
+func (b *AerospikeNamespaceRestore) GetFinishedConditionType() string {
 	return common.ConditionRestoreFinished
 }
 
-func (b *AerospikeNamespaceRestore) GetStartedConditionType() apiextensions.CustomResourceDefinitionConditionType {
+func (b *AerospikeNamespaceRestore) GetStartedConditionType() string {
 	return common.ConditionRestoreStarted
 }
 
@@ -17,7 +17,6 @@ limitations under the License.
 package v1alpha2
 
 import (
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
@@ -32,10 +31,10 @@ type BackupRestoreObject interface {
 	GetStorage() *BackupStorageSpec
 	SetStorage(*BackupStorageSpec)
 	GetTarget() *TargetNamespace
-	GetConditions() []apiextensions.CustomResourceDefinitionCondition
-	SetConditions([]apiextensions.CustomResourceDefinitionCondition)
-	GetFailedConditionType() apiextensions.CustomResourceDefinitionConditionType
-	GetFinishedConditionType() apiextensions.CustomResourceDefinitionConditionType
-	GetStartedConditionType() apiextensions.CustomResourceDefinitionConditionType
+	GetConditions() []v1.Condition
+	SetConditions([]v1.Condition)
+	GetFailedConditionType() string
+	GetFinishedConditionType() string
+	GetStartedConditionType() string
 	SyncStatusWithSpec() bool
 }
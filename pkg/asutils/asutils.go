@@ -17,6 +17,7 @@ limitations under the License.
 package asutils
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -27,12 +28,9 @@ import (
 
 const timeout = 10 * time.Second
 
-func GetClusterSize(host string, port int) (int, error) {
-	c, err := as.NewConnection(&as.ClientPolicy{Timeout: timeout}, &as.Host{Name: host, Port: port})
-	if err != nil {
-		return 0, err
-	}
-	r, err := as.RequestInfo(c, "statistics")
+// GetClusterSize returns the size of the cluster as seen by the Aerospike node at the specified address.
+func GetClusterSize(ctx context.Context, host string, port int) (int, error) {
+	r, err := RequestInfo(ctx, host, port, "statistics")
 	if err != nil {
 		return 0, err
 	}
@@ -43,6 +41,35 @@ func GetClusterSize(host string, port int) (int, error) {
 	}
 }
 
+// RequestInfo runs the specified info commands against the Aerospike node at the specified address. It returns as soon
+// as ctx is done, in which case the connection is closed in the background once the request times out.
+func RequestInfo(ctx context.Context, host string, port int, commands ...string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		res map[string]string
+		err error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		c, err := as.NewConnection(&as.ClientPolicy{Timeout: timeout}, &as.Host{Name: host, Port: port})
+		if err != nil {
+			resCh <- result{nil, err}
+			return
+		}
+		defer c.Close()
+		res, err := as.RequestInfo(c, commands...)
+		resCh <- result{res, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-resCh:
+		return r.res, r.err
+	}
+}
+
 // ParseStatistics parses a string in the form a=b;c=d; into a map[string]string, trimming whitespace in the process.
 func ParseStatistics(stats string) map[string]string {
 	res := make(map[string]string)
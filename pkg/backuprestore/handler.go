@@ -17,15 +17,13 @@ limitations under the License.
 package backuprestore
 
 import (
+	"context"
 	"fmt"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 	batch "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	batchlistersv1 "k8s.io/client-go/listers/batch/v1"
@@ -62,7 +60,7 @@ func New(kubeclientset kubernetes.Interface,
 }
 
 // Handle manages the lifecycle of the obj resource.
-func (h *AerospikeBackupRestoreHandler) Handle(obj aerospikev1alpha2.BackupRestoreObject) error {
+func (h *AerospikeBackupRestoreHandler) Handle(ctx context.Context, obj aerospikev1alpha2.BackupRestoreObject) error {
 	log.WithFields(log.Fields{
 		logfields.Kind: obj.GetKind(),
 		logfields.Key:  meta.Key(obj),
@@ -76,11 +74,11 @@ func (h *AerospikeBackupRestoreHandler) Handle(obj aerospikev1alpha2.BackupResto
 			logfields.Key:  meta.Key(obj),
 		}).Debug("no action is needed")
 		if obj.SyncStatusWithSpec() {
-			if err := h.updateStatus(obj); err != nil {
+			if err := h.updateStatus(ctx, obj); err != nil {
 				return err
 			}
 		}
-		return h.clearSecrets(obj)
+		return h.clearSecrets(ctx, obj)
 	}
 
 	log.WithFields(log.Fields{
@@ -103,12 +101,12 @@ func (h *AerospikeBackupRestoreHandler) Handle(obj aerospikev1alpha2.BackupResto
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// get the secret containing the credentials to access cloud storage
-			secret, err := h.getSecret(obj)
+			secret, err := h.getSecret(ctx, obj)
 			if err != nil {
 				return err
 			}
 			// the job doesn't exist yet, so create it
-			if err := h.launchJob(obj, secret); err != nil {
+			if err := h.launchJob(ctx, obj, secret); err != nil {
 				return err
 			}
 		} else {
@@ -121,14 +119,14 @@ func (h *AerospikeBackupRestoreHandler) Handle(obj aerospikev1alpha2.BackupResto
 	}
 	// sync .status with .spec
 	obj.SyncStatusWithSpec()
-	return h.updateStatus(obj)
+	return h.updateStatus(ctx, obj)
 }
 
 // launchJob performs a number of checks and launches the job associated with
 // obj.
-func (h *AerospikeBackupRestoreHandler) launchJob(obj aerospikev1alpha2.BackupRestoreObject, secret *v1.Secret) error {
+func (h *AerospikeBackupRestoreHandler) launchJob(ctx context.Context, obj aerospikev1alpha2.BackupRestoreObject, secret *v1.Secret) error {
 	// create the backup/restore job
-	job, err := h.createJob(obj, secret)
+	job, err := h.createJob(ctx, obj, secret)
 	if err != nil {
 		return err
 	}
@@ -142,14 +140,9 @@ func (h *AerospikeBackupRestoreHandler) launchJob(obj aerospikev1alpha2.BackupRe
 	h.recorder.Eventf(obj.(runtime.Object),
 		v1.EventTypeNormal, events.ReasonJobCreated,
 		"%s job created as %s", obj.GetOperationType(), meta.Key(job))
-	// append a condition to the resource indicating the current status
-	condition := apiextensions.CustomResourceDefinitionCondition{
-		LastTransitionTime: metav1.NewTime(time.Now()),
-		Type:               obj.GetStartedConditionType(),
-		Status:             apiextensions.ConditionTrue,
-		Message:            fmt.Sprintf("%s job created as %s", obj.GetOperationType(), meta.Key(job)),
-	}
-	obj.SetConditions(append(obj.GetConditions(), condition))
+	// set a condition on the resource indicating the current status
+	setCondition(obj, obj.GetStartedConditionType(), events.ReasonJobCreated,
+		fmt.Sprintf("%s job created as %s", obj.GetOperationType(), meta.Key(job)))
 	return nil
 }
 
@@ -181,13 +174,9 @@ func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2
 		// record an event indicating success
 		h.recorder.Eventf(obj.(runtime.Object), v1.EventTypeNormal, events.ReasonJobFinished,
 			"%s job has finished", obj.GetOperationType())
-		// append a jobCondition to the resource's status indicating success
-		obj.SetConditions(append(obj.GetConditions(), apiextensions.CustomResourceDefinitionCondition{
-			LastTransitionTime: metav1.NewTime(time.Now()),
-			Type:               obj.GetFinishedConditionType(),
-			Status:             apiextensions.ConditionTrue,
-			Message:            fmt.Sprintf("%s job has finished", obj.GetOperationType()),
-		}))
+		// set a condition on the resource's status indicating success
+		setCondition(obj, obj.GetFinishedConditionType(), events.ReasonJobFinished,
+			fmt.Sprintf("%s job has finished", obj.GetOperationType()))
 	case batch.JobFailed:
 		// log that the job failed
 		log.WithFields(log.Fields{
@@ -197,12 +186,8 @@ func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2
 		// record an event indicating failure
 		h.recorder.Eventf(obj.(runtime.Object), v1.EventTypeWarning, events.ReasonJobFailed,
 			"%s job failed %d times", obj.GetOperationType(), job.Status.Failed)
-		// append a jobCondition to the resource's status indicating failure
-		obj.SetConditions(append(obj.GetConditions(), apiextensions.CustomResourceDefinitionCondition{
-			LastTransitionTime: metav1.NewTime(time.Now()),
-			Type:               obj.GetFailedConditionType(),
-			Status:             apiextensions.ConditionTrue,
-			Message:            fmt.Sprintf("%s job failed %d times", obj.GetOperationType(), job.Status.Failed),
-		}))
+		// set a condition on the resource's status indicating failure
+		setCondition(obj, obj.GetFailedConditionType(), events.ReasonJobFailed,
+			fmt.Sprintf("%s job failed %d times", obj.GetOperationType(), job.Status.Failed))
 	}
 }
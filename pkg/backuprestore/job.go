@@ -23,15 +23,16 @@ import (
 	log "github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
-	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 const (
@@ -41,11 +42,19 @@ const (
 )
 
 // createJob creates the job associated with obj.
-func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRestoreObject, secret *corev1.Secret) (*batchv1.Job, error) {
+func (h *AerospikeBackupRestoreHandler) createJob(ctx context.Context, obj aerospikev1alpha2.BackupRestoreObject, secret *corev1.Secret) (*batchv1.Job, error) {
 	secretKey := obj.GetStorage().GetSecretKey()
 	if _, ok := secret.Data[secretKey]; !ok {
 		return nil, fmt.Errorf("secret does not contain expected field %q", secretKey)
 	}
+	// use the images configured for the target cluster, if it still exists
+	var imagesSpec *aerospikev1alpha2.ImagesSpec
+	aerospikeCluster, err := h.aerospikeClustersLister.AerospikeClusters(obj.GetNamespace()).Get(obj.GetTarget().Cluster)
+	if err == nil {
+		imagesSpec = aerospikeCluster.Spec.Images
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
 	job := batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: h.getJobName(obj),
@@ -76,8 +85,8 @@ func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRe
 					Containers: []corev1.Container{
 						{
 							Name:            "aerospike-operator-tools",
-							Image:           fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-							ImagePullPolicy: corev1.PullAlways,
+							Image:           images.Tools(imagesSpec),
+							ImagePullPolicy: images.PullPolicy(imagesSpec, corev1.PullAlways),
 							Command: []string{
 								"backup",
 								string(obj.GetOperationType()),
@@ -97,7 +106,8 @@ func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRe
 							},
 						},
 					},
-					RestartPolicy: corev1.RestartPolicyNever,
+					ImagePullSecrets: images.PullSecrets(imagesSpec),
+					RestartPolicy:    corev1.RestartPolicyNever,
 					Volumes: []corev1.Volume{
 						{
 							Name: secretVolumeName,
@@ -114,7 +124,7 @@ func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRe
 		},
 	}
 
-	res, err := h.kubeclientset.BatchV1().Jobs(obj.GetObjectMeta().Namespace).Create(context.TODO(), &job, metav1.CreateOptions{})
+	res, err := h.kubeclientset.BatchV1().Jobs(obj.GetObjectMeta().Namespace).Create(ctx, &job, metav1.CreateOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -29,33 +29,33 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 )
 
-func (h *AerospikeBackupRestoreHandler) getSecret(obj aerospikev1alpha2.BackupRestoreObject) (*corev1.Secret, error) {
+func (h *AerospikeBackupRestoreHandler) getSecret(ctx context.Context, obj aerospikev1alpha2.BackupRestoreObject) (*corev1.Secret, error) {
 	namespace := obj.GetStorage().GetSecretNamespace(obj.GetNamespace())
-	secret, err := h.kubeclientset.CoreV1().Secrets(namespace).Get(context.TODO(), obj.GetStorage().GetSecret(), metav1.GetOptions{})
+	secret, err := h.kubeclientset.CoreV1().Secrets(namespace).Get(ctx, obj.GetStorage().GetSecret(), metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 	if namespace == obj.GetNamespace() {
 		return secret, nil
 	}
-	return h.createTempSecret(secret, obj)
+	return h.createTempSecret(ctx, secret, obj)
 }
 
-func (h *AerospikeBackupRestoreHandler) clearSecrets(obj aerospikev1alpha2.BackupRestoreObject) error {
-	secrets, err := h.kubeclientset.CoreV1().Secrets(obj.GetNamespace()).List(context.TODO(), listoptions.ResourcesByBackupRestoreObject(obj))
+func (h *AerospikeBackupRestoreHandler) clearSecrets(ctx context.Context, obj aerospikev1alpha2.BackupRestoreObject) error {
+	secrets, err := h.kubeclientset.CoreV1().Secrets(obj.GetNamespace()).List(ctx, listoptions.ResourcesByBackupRestoreObject(obj))
 	if err != nil {
 		return err
 	}
 	for _, secret := range secrets.Items {
-		if err := h.kubeclientset.CoreV1().Secrets(secret.Namespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{}); err != nil {
+		if err := h.kubeclientset.CoreV1().Secrets(secret.Namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{}); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (h *AerospikeBackupRestoreHandler) createTempSecret(secret *corev1.Secret, obj aerospikev1alpha2.BackupRestoreObject) (*corev1.Secret, error) {
-	return h.kubeclientset.CoreV1().Secrets(obj.GetNamespace()).Create(context.TODO(), &corev1.Secret{
+func (h *AerospikeBackupRestoreHandler) createTempSecret(ctx context.Context, secret *corev1.Secret, obj aerospikev1alpha2.BackupRestoreObject) (*corev1.Secret, error) {
+	return h.kubeclientset.CoreV1().Secrets(obj.GetNamespace()).Create(ctx, &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: fmt.Sprintf("%s-", secret.Name),
 			Labels: map[string]string{
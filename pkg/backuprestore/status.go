@@ -19,29 +19,35 @@ package backuprestore
 import (
 	"context"
 
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/conditions"
 )
 
-func (h *AerospikeBackupRestoreHandler) updateStatus(obj aerospikev1alpha2.BackupRestoreObject) error {
+func (h *AerospikeBackupRestoreHandler) updateStatus(ctx context.Context, obj aerospikev1alpha2.BackupRestoreObject) error {
 	var err error
 	switch obj.GetOperationType() {
 	case common.OperationTypeBackup:
-		_, err = h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(obj.GetNamespace()).UpdateStatus(context.TODO(), obj.(*aerospikev1alpha2.AerospikeNamespaceBackup), v1.UpdateOptions{})
+		_, err = h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(obj.GetNamespace()).UpdateStatus(ctx, obj.(*aerospikev1alpha2.AerospikeNamespaceBackup), v1.UpdateOptions{})
 	case common.OperationTypeRestore:
-		_, err = h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceRestores(obj.GetNamespace()).UpdateStatus(context.TODO(), obj.(*aerospikev1alpha2.AerospikeNamespaceRestore), v1.UpdateOptions{})
+		_, err = h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceRestores(obj.GetNamespace()).UpdateStatus(ctx, obj.(*aerospikev1alpha2.AerospikeNamespaceRestore), v1.UpdateOptions{})
 	}
 	return err
 }
 
 func (h *AerospikeBackupRestoreHandler) isFailedOrFinished(obj aerospikev1alpha2.BackupRestoreObject) bool {
-	for _, c := range obj.GetConditions() {
-		if (c.Type == obj.GetFinishedConditionType() || c.Type == obj.GetFailedConditionType()) && c.Status == apiextensions.ConditionTrue {
-			return true
-		}
-	}
-	return false
+	return apimeta.IsStatusConditionTrue(obj.GetConditions(), obj.GetFinishedConditionType()) ||
+		apimeta.IsStatusConditionTrue(obj.GetConditions(), obj.GetFailedConditionType())
+}
+
+// setCondition sets the condition with the specified type to True in the
+// status of obj, and the remaining started/finished/failed conditions to False.
+func setCondition(obj aerospikev1alpha2.BackupRestoreObject, conditionType, reason, message string) {
+	group := []string{obj.GetStartedConditionType(), obj.GetFinishedConditionType(), obj.GetFailedConditionType()}
+	res := obj.GetConditions()
+	conditions.SetExclusive(&res, group, conditionType, obj.GetObjectMeta().Generation, reason, message)
+	obj.SetConditions(res)
 }
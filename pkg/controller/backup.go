@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 
 	batchv1 "k8s.io/api/batch/v1"
@@ -107,7 +108,7 @@ func NewAerospikeNamespaceBackupController(
 }
 
 // processQueueItem compares the actual state with the desired, and attempts to converge the two
-func (c *AerospikeNamespaceBackupController) processQueueItem(key string) error {
+func (c *AerospikeNamespaceBackupController) processQueueItem(ctx context.Context, key string) error {
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -128,7 +129,7 @@ func (c *AerospikeNamespaceBackupController) processQueueItem(key string) error
 	}
 
 	// deepcopy aerospikeNamespaceRestore before handle it so we don't possibly mutate the cache
-	return c.handler.Handle(aerospikeNamespaceBackup.DeepCopy())
+	return c.handler.Handle(ctx, aerospikeNamespaceBackup.DeepCopy())
 }
 
 // handleObject will take any resource implementing metav1.Object and attempt
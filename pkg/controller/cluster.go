@@ -17,16 +17,20 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	aerospikeclientset "github.com/travelaudience/aerospike-operator/pkg/client/clientset/versioned"
 	aerospikeinformers "github.com/travelaudience/aerospike-operator/pkg/client/informers/externalversions"
 	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
@@ -35,9 +39,9 @@ import (
 
 const (
 	// clusterControllerDefaultThreadiness is the number of workers the cluster
-	// controller will use to process items from the queue. since every worker
-	// will usually block for a long time when reconciling an aerospikecluster
-	// resource we must use a value that is higher than the usual.
+	// controller will use to process items from the queue. reconciling an
+	// aerospikecluster resource never waits for long operations to finish, but
+	// may still involve several calls to aerospike nodes.
 	clusterControllerDefaultThreadiness = 6
 )
 
@@ -52,6 +56,7 @@ type AerospikeClusterController struct {
 func NewAerospikeClusterController(
 	kubeClient kubernetes.Interface,
 	aerospikeClient aerospikeclientset.Interface,
+	dynamicClient dynamic.Interface,
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
 	aerospikeInformerFactory aerospikeinformers.SharedInformerFactory) *AerospikeClusterController {
 
@@ -86,15 +91,28 @@ func NewAerospikeClusterController(
 		aerospikeClusterInformer.Informer().HasSynced,
 	}
 	c.syncHandler = c.processQueueItem
-	c.reconciler = reconciler.New(kubeClient, aerospikeClient, podsLister, configMapsLister, servicesLister, pvcsLister, scsLister, aerospikeNamespaceBackupsLister, c.recorder)
+	// reconciliation is resumable and safe to retry after a failure
+	c.retryOnError = true
+	c.reconciler = reconciler.New(kubeClient, aerospikeClient, dynamicClient, podsLister, configMapsLister, servicesLister, pvcsLister, scsLister, aerospikeNamespaceBackupsLister, c.recorder)
 
 	c.logger.Debug("setting up event handlers")
 
 	// setup an event handler for when AerospikeCluster resources change
 	aerospikeClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: c.enqueue,
-		UpdateFunc: func(_, obj interface{}) {
-			c.enqueue(obj)
+		UpdateFunc: func(old, new interface{}) {
+			oldCluster := old.(*aerospikev1alpha2.AerospikeCluster)
+			newCluster := new.(*aerospikev1alpha2.AerospikeCluster)
+			// Changes to the status alone are the result of processing the
+			// AerospikeCluster resource, and must not cause it to be
+			// processed again. Periodic resyncs are still processed.
+			if newCluster.ResourceVersion != oldCluster.ResourceVersion &&
+				newCluster.Generation == oldCluster.Generation &&
+				reflect.DeepEqual(newCluster.Annotations, oldCluster.Annotations) &&
+				reflect.DeepEqual(newCluster.Labels, oldCluster.Labels) {
+				return
+			}
+			c.enqueue(new)
 		},
 	})
 	// setup an event handler for when Pod resources change. This
@@ -122,7 +140,7 @@ func NewAerospikeClusterController(
 }
 
 // processQueueItem compares the actual state with the desired, and attempts to converge the two
-func (c *AerospikeClusterController) processQueueItem(key string) error {
+func (c *AerospikeClusterController) processQueueItem(ctx context.Context, key string) error {
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -143,7 +161,7 @@ func (c *AerospikeClusterController) processQueueItem(key string) error {
 	}
 
 	// deepcopy aerospikeCluster before reconciling so we don't possibly mutate the cache
-	return c.reconciler.MaybeReconcile(aerospikeCluster.DeepCopy())
+	return c.reconciler.MaybeReconcile(ctx, aerospikeCluster.DeepCopy())
 }
 
 // handleObject will take any resource implementing metav1.Object and attempt
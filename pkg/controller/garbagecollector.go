@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -106,7 +107,7 @@ func NewGarbageCollectorController(
 }
 
 // processQueueItem compares the actual state with the desired, and attempts to converge the two
-func (c *AerospikeGarbageCollectorController) processQueueItem(prefixedKey string) error {
+func (c *AerospikeGarbageCollectorController) processQueueItem(ctx context.Context, prefixedKey string) error {
 	ss := strings.Split(prefixedKey, ":")
 	if len(ss) != 2 {
 		return fmt.Errorf("invalid key format for garbagecollector controller: %q", prefixedKey)
@@ -132,7 +133,7 @@ func (c *AerospikeGarbageCollectorController) processQueueItem(prefixedKey strin
 			}
 			return err
 		}
-		return c.aerospikeNamespaceBackupsHandler.Handle(aerospikeNamespaceBackup.DeepCopy())
+		return c.aerospikeNamespaceBackupsHandler.Handle(ctx, aerospikeNamespaceBackup.DeepCopy())
 	case pvcPrefix:
 		// Get the PersistentVolumeClaim resource with this namespace/name
 		pvc, err := c.pvcsLister.PersistentVolumeClaims(namespace).Get(name)
@@ -144,7 +145,7 @@ func (c *AerospikeGarbageCollectorController) processQueueItem(prefixedKey strin
 			}
 			return err
 		}
-		return c.pvcsHandler.Handle(pvc.DeepCopy())
+		return c.pvcsHandler.Handle(ctx, pvc.DeepCopy())
 	default:
 		return fmt.Errorf("invalid prefix %q", prefix)
 	}
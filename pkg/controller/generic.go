@@ -17,7 +17,9 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -30,6 +32,17 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
+
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+)
+
+const (
+	// requeueBaseDelay is the initial delay after which an item whose
+	// processing is not finished is processed again
+	requeueBaseDelay = 2 * time.Second
+	// requeueMaxDelay is the maximum delay after which an item whose
+	// processing is not finished is processed again
+	requeueMaxDelay = 1 * time.Minute
 )
 
 // Controller encapsulates a controller for Kubernetes resources.
@@ -49,16 +62,25 @@ type genericController struct {
 	// time, and makes it easy to ensure we are never processing the same item
 	// simultaneously in two different workers.
 	workqueue workqueue.RateLimitingInterface
+	// requeueRateLimiter computes the delay after which an item whose
+	// processing is not finished yet is processed again. it backs off
+	// exponentially for as long as the item keeps being requeued.
+	requeueRateLimiter workqueue.RateLimiter
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
 
 	// hasSyncedFuncs are the functions used to determine if caches are synced
 	hasSyncedFuncs []cache.InformerSynced
-	// syncHandler is a function that takes a key (namespace/name) and processes the corresponding object
-	syncHandler func(key string) error
+	// syncHandler is a function that takes a key (namespace/name) and processes the corresponding object. ctx is
+	// cancelled when the controller is asked to stop.
+	syncHandler func(ctx context.Context, key string) error
 	// the number of workers to use for processing items from the workqueue.
 	threadiness int
+	// retryOnError indicates whether items whose processing fails are put
+	// back on the workqueue with a rate-limited delay. it must only be set by
+	// controllers whose syncHandler is safe to retry right away.
+	retryOnError bool
 }
 
 // newGenericController returns a new generic controller
@@ -71,21 +93,28 @@ func newGenericController(name string, threadiness int, kubeClient kubernetes.In
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: name})
 
 	return &genericController{
-		logger:      logger,
-		workqueue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
-		recorder:    recorder,
-		threadiness: threadiness,
+		logger:             logger,
+		workqueue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
+		requeueRateLimiter: workqueue.NewItemExponentialFailureRateLimiter(requeueBaseDelay, requeueMaxDelay),
+		recorder:           recorder,
+		threadiness:        threadiness,
 	}
 }
 
 // Run will set up the event handlers for types we are interested in, as well
 // as syncing informer caches and starting workers. It will block until stopCh
-// is closed, at which point it will shutdown the workqueue and wait for
-// workers to finish processing their current work items.
+// is closed, at which point it will cancel the context passed to the workers,
+// shutdown the workqueue and wait for workers to finish processing their
+// current work items.
 func (c *genericController) Run(stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
 	defer c.workqueue.ShutDown()
 
+	// derive a context from stopCh so that in-flight work is aborted as soon
+	// as the controller is asked to stop
+	ctx, cancel := wait.ContextForChannel(stopCh)
+	defer cancel()
+
 	// Start the informer factories to begin populating the informer caches
 	c.logger.Debug("starting controller")
 
@@ -96,34 +125,50 @@ func (c *genericController) Run(stopCh <-chan struct{}) error {
 	}
 
 	c.logger.Debug("starting workers")
-	// Launch two workers to process AerospikeCluster resources
+	var wg sync.WaitGroup
 	for i := 0; i < c.threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.UntilWithContext(ctx, c.runWorker, time.Second)
+		}()
 	}
 
 	c.logger.Info("started workers")
-	<-stopCh
+	<-ctx.Done()
 	c.logger.Info("shutting down workers")
 
+	// shutdown the workqueue so that idle workers return, and wait for the
+	// remaining ones to abort their current work items
+	c.workqueue.ShutDown()
+	wg.Wait()
+	c.logger.Info("workers have been shut down")
+
 	return nil
 }
 
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
-func (c *genericController) runWorker() {
-	for c.processNextWorkItem() {
+func (c *genericController) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
 	}
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the syncHandler.
-func (c *genericController) processNextWorkItem() bool {
+func (c *genericController) processNextWorkItem(ctx context.Context) bool {
 	obj, shutdown := c.workqueue.Get()
 
 	if shutdown {
 		return false
 	}
+	// the workqueue hands out the items it still holds after being shutdown,
+	// which we must not process if we have been asked to stop
+	if ctx.Err() != nil {
+		c.workqueue.Done(obj)
+		return false
+	}
 
 	// We wrap this block in a func so we can defer c.workqueue.Done.
 	err := func(obj interface{}) error {
@@ -151,12 +196,39 @@ func (c *genericController) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// AerospikeCluster resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		if err := c.syncHandler(ctx, key); err != nil {
+			// If processing was aborted because we have been asked to stop,
+			// there is nothing to report. Processing is resumed by whoever
+			// takes over, based on the state recorded in the resource.
+			if ctx.Err() != nil {
+				c.logger.Infof("aborted processing of '%s': %s", key, ctx.Err())
+				return nil
+			}
+			// If processing is not finished yet, we put the item back on
+			// the workqueue so that processing is resumed after a delay
+			// that grows for as long as the item keeps being requeued.
+			if requeue, ok := errors.AsRequeueError(err); ok {
+				delay := c.requeueRateLimiter.When(obj)
+				if delay < requeue.After {
+					delay = requeue.After
+				}
+				c.workqueue.Forget(obj)
+				c.workqueue.AddAfter(obj, delay)
+				c.logger.Debugf("requeued '%s' after %s: %s", key, delay, requeue.Reason)
+				return nil
+			}
+			// If the controller asked for it, we retry processing after a
+			// back-off period, as nothing else may cause the item to be
+			// processed again until the next resync.
+			if c.retryOnError {
+				c.workqueue.AddRateLimited(obj)
+			}
 			return fmt.Errorf("error syncing '%s': %s", key, err.Error())
 		}
 		// Finally, if no error occurs we Forget this item so it does not
 		// get queued again until another change happens.
 		c.workqueue.Forget(obj)
+		c.requeueRateLimiter.Forget(obj)
 		c.logger.Debugf("successfully synced '%s'", key)
 		return nil
 	}(obj)
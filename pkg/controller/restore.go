@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 
 	batchv1 "k8s.io/api/batch/v1"
@@ -107,7 +108,7 @@ func NewAerospikeNamespaceRestoreController(
 }
 
 // processQueueItem compares the actual state with the desired, and attempts to converge the two
-func (c *AerospikeNamespaceRestoreController) processQueueItem(key string) error {
+func (c *AerospikeNamespaceRestoreController) processQueueItem(ctx context.Context, key string) error {
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -128,7 +129,7 @@ func (c *AerospikeNamespaceRestoreController) processQueueItem(key string) error
 	}
 
 	// deepcopy aerospikeNamespaceRestore before handle it so we don't possibly mutate the cache
-	return c.handler.Handle(aerospikeNamespaceRestore.DeepCopy())
+	return c.handler.Handle(ctx, aerospikeNamespaceRestore.DeepCopy())
 }
 
 // handleObject will take any resource implementing metav1.Object and attempt
@@ -19,6 +19,7 @@ package crd
 import (
 	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	extsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +47,9 @@ const (
 	// ttlPattern is the regex used to match a number of days (with
 	// optional fraction) suffixed with a "d"
 	ttlPattern = `^([0-9]*[.])?[0-9]+d$`
+	// digestPattern is the regex used to match the digest of a container
+	// image
+	digestPattern = `^sha256:[a-f0-9]{64}$`
 )
 
 var (
@@ -55,6 +59,111 @@ var (
 )
 
 var (
+	nodeSelectorProps = extsv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: pointers.NewBool(true),
+	}
+
+	resourceRequirementsProps = extsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extsv1.JSONSchemaProps{
+			"limits": {
+				Type: "object",
+				Properties: map[string]extsv1.JSONSchemaProps{
+					"cpu": {
+						Type: "string",
+					},
+					"memory": {
+						Type: "string",
+					},
+				},
+			},
+			"requests": {
+				Type: "object",
+				Properties: map[string]extsv1.JSONSchemaProps{
+					"cpu": {
+						Type: "string",
+					},
+					"memory": {
+						Type: "string",
+					},
+				},
+			},
+		},
+	}
+
+	tolerationsProps = extsv1.JSONSchemaProps{
+		Type: "array",
+		Items: &extsv1.JSONSchemaPropsOrArray{
+			Schema: &extsv1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]extsv1.JSONSchemaProps{
+					"effect": {
+						Type: "string",
+					},
+					"key": {
+						Type: "string",
+					},
+					"operator": {
+						Type: "string",
+					},
+					"tolerationSeconds": {
+						Type: "integer",
+					},
+					"value": {
+						Type: "string",
+					},
+				},
+			},
+		},
+	}
+
+	// the schema of the fields holding arbitrary kubernetes objects, which are
+	// validated by the api server when the pods are created
+	preservedObjectProps = extsv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: pointers.NewBool(true),
+	}
+
+	preservedObjectArrayProps = extsv1.JSONSchemaProps{
+		Type: "array",
+		Items: &extsv1.JSONSchemaPropsOrArray{
+			Schema: &preservedObjectProps,
+		},
+	}
+
+	stringMapProps = extsv1.JSONSchemaProps{
+		Type: "object",
+		AdditionalProperties: &extsv1.JSONSchemaPropsOrBool{
+			Schema: &extsv1.JSONSchemaProps{
+				Type: "string",
+			},
+		},
+	}
+
+	imageProps = extsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extsv1.JSONSchemaProps{
+			"repository": {
+				Type:      "string",
+				MinLength: pointers.NewInt64(1),
+			},
+			"digest": {
+				Type:    "string",
+				Pattern: digestPattern,
+			},
+		},
+	}
+
+	aerospikeConfigStanzaProps = extsv1.JSONSchemaProps{
+		Type: "object",
+		AdditionalProperties: &extsv1.JSONSchemaPropsOrBool{
+			Schema: &extsv1.JSONSchemaProps{
+				Type: "string",
+			},
+		},
+	}
+
 	backupStorageSpecProps = extsv1.JSONSchemaProps{
 		Type: "object",
 		Properties: map[string]extsv1.JSONSchemaProps{
@@ -130,62 +239,9 @@ var (
 												Maximum: pointers.NewFloat64(8),
 												Minimum: pointers.NewFloat64(1),
 											},
-											"nodeSelector": {
-												Type:                   "object",
-												XPreserveUnknownFields: pointers.NewBool(true),
-											},
-											"resources": {
-												Type: "object",
-												Properties: map[string]extsv1.JSONSchemaProps{
-													"limits": {
-														Type: "object",
-														Properties: map[string]extsv1.JSONSchemaProps{
-															"cpu": {
-																Type: "string",
-															},
-															"memory": {
-																Type: "string",
-															},
-														},
-													},
-													"requests": {
-														Type: "object",
-														Properties: map[string]extsv1.JSONSchemaProps{
-															"cpu": {
-																Type: "string",
-															},
-															"memory": {
-																Type: "string",
-															},
-														},
-													},
-												},
-											},
-											"tolerations": {
-												Type: "array",
-												Items: &extsv1.JSONSchemaPropsOrArray{
-													Schema: &extsv1.JSONSchemaProps{
-														Type: "object",
-														Properties: map[string]extsv1.JSONSchemaProps{
-															"effect": {
-																Type: "string",
-															},
-															"key": {
-																Type: "string",
-															},
-															"operator": {
-																Type: "string",
-															},
-															"tolerationSeconds": {
-																Type: "integer",
-															},
-															"value": {
-																Type: "string",
-															},
-														},
-													},
-												},
-											},
+											"nodeSelector": nodeSelectorProps,
+											"resources":    resourceRequirementsProps,
+											"tolerations":  tolerationsProps,
 											"version": {
 												Type:    "string",
 												Pattern: `^\d+\.\d+\.\d+(\.\d+)?$`,
@@ -244,6 +300,7 @@ var (
 																	"size",
 																},
 															},
+															"aerospikeConfig": aerospikeConfigStanzaProps,
 														},
 														Required: []string{
 															"name",
@@ -260,11 +317,200 @@ var (
 														Pattern: ttlPattern,
 													},
 													"storage": backupStorageSpecProps,
+													"backupRemovedNamespaces": {
+														Type: "boolean",
+													},
 												},
 												Required: []string{
 													"storage",
 												},
 											},
+											"aerospikeConfig": {
+												Type: "object",
+												Properties: map[string]extsv1.JSONSchemaProps{
+													"service":   aerospikeConfigStanzaProps,
+													"network":   aerospikeConfigStanzaProps,
+													"namespace": aerospikeConfigStanzaProps,
+												},
+											},
+											"nodeGroups": {
+												Type: "array",
+												Items: &extsv1.JSONSchemaPropsOrArray{
+													Schema: &extsv1.JSONSchemaProps{
+														Type: "object",
+														Properties: map[string]extsv1.JSONSchemaProps{
+															"name": {
+																Type:      "string",
+																MinLength: pointers.NewInt64(1),
+																MaxLength: pointers.NewInt64(63),
+																Pattern:   `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`,
+															},
+															"nodeCount": {
+																Type:    "integer",
+																Maximum: pointers.NewFloat64(8),
+																Minimum: pointers.NewFloat64(0),
+															},
+															"nodeSelector": nodeSelectorProps,
+															"resources":    resourceRequirementsProps,
+															"tolerations":  tolerationsProps,
+														},
+														Required: []string{
+															"name",
+															"nodeCount",
+														},
+													},
+												},
+											},
+											"podTemplate": {
+												Type: "object",
+												Properties: map[string]extsv1.JSONSchemaProps{
+													"labels":      stringMapProps,
+													"annotations": stringMapProps,
+													"priorityClassName": {
+														Type: "string",
+													},
+													"serviceAccountName": {
+														Type: "string",
+													},
+													"securityContext":           preservedObjectProps,
+													"topologySpreadConstraints": preservedObjectArrayProps,
+													"antiAffinity": {
+														Type: "string",
+														Enum: []extsv1.JSON{
+															{Raw: []byte(asstrings.DoubleQuoted(common.PodAntiAffinityHard))},
+															{Raw: []byte(asstrings.DoubleQuoted(common.PodAntiAffinitySoft))},
+														},
+													},
+													"volumes":        preservedObjectArrayProps,
+													"initContainers": preservedObjectArrayProps,
+													"containers":     preservedObjectArrayProps,
+												},
+											},
+											"images": {
+												Type: "object",
+												Properties: map[string]extsv1.JSONSchemaProps{
+													"aerospikeServer": imageProps,
+													"tools":           imageProps,
+													"pullPolicy": {
+														Type: "string",
+														Enum: []extsv1.JSON{
+															{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullAlways)))},
+															{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullIfNotPresent)))},
+															{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullNever)))},
+														},
+													},
+													"pullSecrets": {
+														Type: "array",
+														Items: &extsv1.JSONSchemaPropsOrArray{
+															Schema: &extsv1.JSONSchemaProps{
+																Type: "object",
+																Properties: map[string]extsv1.JSONSchemaProps{
+																	"name": {
+																		Type:      "string",
+																		MinLength: pointers.NewInt64(1),
+																	},
+																},
+																Required: []string{
+																	"name",
+																},
+															},
+														},
+													},
+												},
+											},
+											"monitoring": {
+												Type: "object",
+												Properties: map[string]extsv1.JSONSchemaProps{
+													"enabled": {
+														Type: "boolean",
+													},
+													"image": {
+														Type:      "string",
+														MinLength: pointers.NewInt64(1),
+													},
+													"port": {
+														Type:    "integer",
+														Minimum: pointers.NewFloat64(1),
+														Maximum: pointers.NewFloat64(65535),
+													},
+													"args": {
+														Type: "array",
+														Items: &extsv1.JSONSchemaPropsOrArray{
+															Schema: &extsv1.JSONSchemaProps{
+																Type: "string",
+															},
+														},
+													},
+													"resources": resourceRequirementsProps,
+													"prometheusOperator": {
+														Type: "object",
+														Properties: map[string]extsv1.JSONSchemaProps{
+															"enabled": {
+																Type: "boolean",
+															},
+															"labels": stringMapProps,
+															"interval": {
+																Type:    "string",
+																Pattern: `^([0-9]+(ms|s|m|h))+$`,
+															},
+															"alerts": {
+																Type: "boolean",
+															},
+														},
+														Required: []string{
+															"enabled",
+														},
+													},
+												},
+											},
+											"paused": {
+												Type: "boolean",
+											},
+											"restartGeneration": {
+												Type:    "integer",
+												Minimum: pointers.NewFloat64(0),
+											},
+											"rollout": {
+												Type: "object",
+												Properties: map[string]extsv1.JSONSchemaProps{
+													"stabilizationTimeout": {
+														Type:    "string",
+														Pattern: `^([0-9]+(ms|s|m|h))+$`,
+													},
+													"quiesceTimeout": {
+														Type:    "string",
+														Pattern: `^([0-9]+(ms|s|m|h))+$`,
+													},
+												},
+											},
+											"racks": {
+												Type: "array",
+												Items: &extsv1.JSONSchemaPropsOrArray{
+													Schema: &extsv1.JSONSchemaProps{
+														Type: "object",
+														Properties: map[string]extsv1.JSONSchemaProps{
+															"id": {
+																Type:    "integer",
+																Minimum: pointers.NewFloat64(1),
+																Maximum: pointers.NewFloat64(1000000),
+															},
+															"zone": {
+																Type:      "string",
+																MinLength: pointers.NewInt64(1),
+															},
+															"nodeSelector": nodeSelectorProps,
+															"weight": {
+																Type:    "integer",
+																Minimum: pointers.NewFloat64(1),
+																Maximum: pointers.NewFloat64(8),
+															},
+														},
+														Required: []string{
+															"id",
+														},
+													},
+												},
+											},
 										},
 										Required: []string{
 											"nodeCount",
@@ -272,6 +518,12 @@ var (
 											"namespaces",
 										},
 									},
+									// the status mirrors the spec of the last successful
+									// reconcile, and must be preserved as-is
+									"status": {
+										Type:                   "object",
+										XPreserveUnknownFields: pointers.NewBool(true),
+									},
 								},
 							},
 						},
@@ -296,6 +548,19 @@ var (
 								Description: "The number of nodes in the Aerospike cluster",
 								JSONPath:    ".status.nodeCount",
 							},
+							{
+								Name:        "Phase",
+								Type:        "string",
+								Description: "The phase the Aerospike cluster is in",
+								JSONPath:    ".status.phase",
+							},
+							{
+								Name:        "Operation",
+								Type:        "string",
+								Description: "The operation currently being performed on the Aerospike cluster",
+								JSONPath:    ".status.operation",
+								Priority:    1,
+							},
 							{
 								Name:        "Age",
 								Type:        "date",
@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"fmt"
+	"time"
+)
+
+// RequeueError signals that the processing of an item is not finished yet
+// (e.g. because a pod is still starting) and must be resumed later. It is not
+// regarded as a failure.
+type RequeueError struct {
+	// the reason why processing must be resumed later
+	Reason string
+	// the minimum amount of time to wait before resuming processing
+	After time.Duration
+}
+
+func (e *RequeueError) Error() string {
+	return fmt.Sprintf("requeue after %s: %s", e.After, e.Reason)
+}
+
+// NewRequeueError returns a RequeueError with the specified minimum delay and
+// formatted reason.
+func NewRequeueError(after time.Duration, format string, args ...interface{}) error {
+	return &RequeueError{
+		Reason: fmt.Sprintf(format, args...),
+		After:  after,
+	}
+}
+
+// AsRequeueError returns the specified error as a RequeueError, as well as
+// whether the conversion was possible.
+func AsRequeueError(err error) (*RequeueError, bool) {
+	res, ok := err.(*RequeueError)
+	return res, ok
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import "fmt"
+
+// UnstableClusterError signals that an Aerospike cluster did not become stable
+// in a timely manner, and that the operation in progress has been halted until
+// it does.
+type UnstableClusterError struct {
+	// the reason why the cluster is regarded as unstable
+	Reason string
+}
+
+func (e *UnstableClusterError) Error() string {
+	return e.Reason
+}
+
+// NewUnstableClusterError returns an UnstableClusterError with the specified
+// formatted reason.
+func NewUnstableClusterError(format string, args ...interface{}) error {
+	return &UnstableClusterError{
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// AsUnstableClusterError returns the specified error as an
+// UnstableClusterError, as well as whether the conversion was possible.
+func AsUnstableClusterError(err error) (*UnstableClusterError, bool) {
+	res, ok := err.(*UnstableClusterError)
+	return res, ok
+}
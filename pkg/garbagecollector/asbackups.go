@@ -54,13 +54,13 @@ func NewAerospikeNamespaceBackupHandler(kubeclientset kubernetes.Interface,
 	}
 }
 
-func (h *AerospikeNamespaceBackupHandler) Handle(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
+func (h *AerospikeNamespaceBackupHandler) Handle(ctx context.Context, asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
 	log.WithFields(log.Fields{
 		logfields.Key: meta.Key(asBackup),
 	}).Debug("checking whether aerospikenamespacebackup has expired")
 
 	// get the corresponding aerospikecluster object
-	aerospikeCluster, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(asBackup.Namespace).Get(context.TODO(), asBackup.Spec.Target.Cluster, v1.GetOptions{})
+	aerospikeCluster, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(asBackup.Namespace).Get(ctx, asBackup.Spec.Target.Cluster, v1.GetOptions{})
 	if err != nil {
 		return err
 	}
@@ -105,7 +105,7 @@ func (h *AerospikeNamespaceBackupHandler) Handle(asBackup *aerospikev1alpha2.Aer
 		// delete backup data from cloud storage
 		switch asBackup.Spec.Storage.Type {
 		case common.StorageTypeGCS:
-			if err := h.deleteBackupDataGCS(asBackup); err != nil {
+			if err := h.deleteBackupDataGCS(ctx, asBackup); err != nil {
 				log.WithFields(log.Fields{
 					logfields.Key: meta.Key(asBackup),
 				}).Infof("could not delete backup data from cloud storage: %s", err)
@@ -118,7 +118,7 @@ func (h *AerospikeNamespaceBackupHandler) Handle(asBackup *aerospikev1alpha2.Aer
 		}
 
 		// delete AerospikeNamespaceBackup resource
-		if err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(asBackup.Namespace).Delete(context.TODO(), asBackup.Name, v1.DeleteOptions{}); err != nil {
+		if err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(asBackup.Namespace).Delete(ctx, asBackup.Name, v1.DeleteOptions{}); err != nil {
 			return err
 		}
 		log.WithFields(log.Fields{
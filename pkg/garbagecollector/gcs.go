@@ -26,10 +26,10 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/gcs"
 )
 
-func (h *AerospikeNamespaceBackupHandler) deleteBackupDataGCS(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
+func (h *AerospikeNamespaceBackupHandler) deleteBackupDataGCS(ctx context.Context, asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
 	// get the secret containing the credentials to access the gcs bucket
 	namespace := asBackup.Spec.Storage.GetSecretNamespace(asBackup.Namespace)
-	secret, err := h.kubeclientset.CoreV1().Secrets(namespace).Get(context.TODO(), asBackup.Spec.Storage.GetSecret(), v1.GetOptions{})
+	secret, err := h.kubeclientset.CoreV1().Secrets(namespace).Get(ctx, asBackup.Spec.Storage.GetSecret(), v1.GetOptions{})
 	if err != nil {
 		return err
 	}
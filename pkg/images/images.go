@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+var (
+	// AerospikeServerRepository is the repository from which to pull the
+	// Aerospike Server image, unless overridden in an AerospikeCluster.
+	AerospikeServerRepository = "aerospike/aerospike-server"
+	// ToolsRepository is the repository from which to pull the
+	// aerospike-operator-tools image, unless overridden in an AerospikeCluster.
+	ToolsRepository = "quay.io/travelaudience/aerospike-operator-tools"
+)
+
+// AerospikeServer returns the Aerospike Server image with the specified
+// version to be used according to the specified images spec.
+func AerospikeServer(images *aerospikev1alpha2.ImagesSpec, version string) string {
+	var image *aerospikev1alpha2.ImageSpec
+	if images != nil {
+		image = images.AerospikeServer
+	}
+	return format(image, AerospikeServerRepository, version)
+}
+
+// Tools returns the aerospike-operator-tools image to be used according to the
+// specified images spec.
+func Tools(images *aerospikev1alpha2.ImagesSpec) string {
+	var image *aerospikev1alpha2.ImageSpec
+	if images != nil {
+		image = images.Tools
+	}
+	return format(image, ToolsRepository, versioning.OperatorVersion)
+}
+
+// PullPolicy returns the pull policy specified in the images spec, or def if
+// none has been specified.
+func PullPolicy(images *aerospikev1alpha2.ImagesSpec, def corev1.PullPolicy) corev1.PullPolicy {
+	if images != nil && images.PullPolicy != "" {
+		return images.PullPolicy
+	}
+	return def
+}
+
+// PullSecrets returns the pull secrets specified in the images spec.
+func PullSecrets(images *aerospikev1alpha2.ImagesSpec) []corev1.LocalObjectReference {
+	if images == nil {
+		return nil
+	}
+	return images.PullSecrets
+}
+
+// format returns the reference to the specified image, using the specified
+// default repository if the image does not override it. the tag is kept when
+// a digest is specified so that the version remains visible.
+func format(image *aerospikev1alpha2.ImageSpec, defaultRepository, tag string) string {
+	repository := defaultRepository
+	if image != nil && image.Repository != "" {
+		repository = image.Repository
+	}
+	if image != nil && image.Digest != "" {
+		return fmt.Sprintf("%s:%s@%s", repository, tag, image.Digest)
+	}
+	return fmt.Sprintf("%s:%s", repository, tag)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestAerospikeServer(t *testing.T) {
+	tests := []struct {
+		images   *aerospikev1alpha2.ImagesSpec
+		expected string
+	}{
+		{nil, "aerospike/aerospike-server:4.2.0.10"},
+		{&aerospikev1alpha2.ImagesSpec{}, "aerospike/aerospike-server:4.2.0.10"},
+		{&aerospikev1alpha2.ImagesSpec{AerospikeServer: &aerospikev1alpha2.ImageSpec{Repository: "mirror.example.com/aerospike-server"}}, "mirror.example.com/aerospike-server:4.2.0.10"},
+		{&aerospikev1alpha2.ImagesSpec{AerospikeServer: &aerospikev1alpha2.ImageSpec{Digest: digest}}, "aerospike/aerospike-server:4.2.0.10@" + digest},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, AerospikeServer(test.images, "4.2.0.10"))
+	}
+}
+
+func TestTools(t *testing.T) {
+	tests := []struct {
+		images   *aerospikev1alpha2.ImagesSpec
+		expected string
+	}{
+		{nil, "quay.io/travelaudience/aerospike-operator-tools:" + versioning.OperatorVersion},
+		{&aerospikev1alpha2.ImagesSpec{Tools: &aerospikev1alpha2.ImageSpec{Repository: "mirror.example.com/tools", Digest: digest}}, "mirror.example.com/tools:" + versioning.OperatorVersion + "@" + digest},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Tools(test.images))
+	}
+}
+
+func TestPullPolicy(t *testing.T) {
+	tests := []struct {
+		images   *aerospikev1alpha2.ImagesSpec
+		expected corev1.PullPolicy
+	}{
+		{nil, corev1.PullAlways},
+		{&aerospikev1alpha2.ImagesSpec{}, corev1.PullAlways},
+		{&aerospikev1alpha2.ImagesSpec{PullPolicy: corev1.PullIfNotPresent}, corev1.PullIfNotPresent},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, PullPolicy(test.images, corev1.PullAlways))
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"strings"
 
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -29,14 +31,17 @@ import (
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
 )
 
-func (r *AerospikeClusterReconciler) backupCluster(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+func (r *AerospikeClusterReconciler) backupCluster(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	// create a backup of each namespace specified in .spec.namespaces
 	for _, namespace := range aerospikeCluster.Spec.Namespaces {
-		if err := r.createNamespaceBackup(aerospikeCluster, namespace.Name); err != nil {
+		if err := r.createNamespaceBackup(ctx, aerospikeCluster, namespace.Name, GetBackupName(aerospikeCluster.Name, namespace.Name, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version)); err != nil {
 			return err
 		}
 	}
@@ -46,7 +51,7 @@ func (r *AerospikeClusterReconciler) backupCluster(aerospikeCluster *aerospikev1
 func (r *AerospikeClusterReconciler) isClusterBackupFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
 	// if the backup of one of the namespaces have not finished, return false
 	for _, namespace := range aerospikeCluster.Spec.Namespaces {
-		if finished, err := r.isBackupCompleted(aerospikeCluster, namespace.Name); err != nil {
+		if finished, err := r.isBackupCompleted(aerospikeCluster, GetBackupName(aerospikeCluster.Name, namespace.Name, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version)); err != nil {
 			return false, err
 		} else if !finished {
 			return false, nil
@@ -55,10 +60,71 @@ func (r *AerospikeClusterReconciler) isClusterBackupFinished(aerospikeCluster *a
 	return true, nil
 }
 
-func (r *AerospikeClusterReconciler) createNamespaceBackup(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ns string) error {
+// backupRemovedNamespaces makes a final backup of every namespace that has been
+// removed from .spec.namespaces, and reports whether all of them have finished.
+func (r *AerospikeClusterReconciler) backupRemovedNamespaces(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
+	// final backups are only made if .spec.backupSpec is specified and they
+	// have not been disabled
+	if !aerospikeCluster.Spec.BackupSpec.ShouldBackupRemovedNamespaces() {
+		return true, nil
+	}
+
+	finished := true
+	for _, ns := range getRemovedNamespaces(aerospikeCluster) {
+		// the last reconciled generation remains the same until the namespace
+		// has been removed, so that changes made to the spec in the meantime
+		// don't cause a new backup to be made
+		name := GetNamespaceRemovalBackupName(aerospikeCluster.Name, ns, aerospikeCluster.Status.ObservedGeneration)
+		// create the backup if it doesn't exist yet
+		if _, err := r.aerospikeBackupsLister.AerospikeNamespaceBackups(aerospikeCluster.Namespace).Get(name); err != nil {
+			if !kubeerrors.IsNotFound(err) {
+				return false, err
+			}
+			if err := r.createNamespaceBackup(ctx, aerospikeCluster, ns, name); err != nil {
+				return false, err
+			}
+			r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNamespaceRemovalBackupStarted,
+				"backing up namespace %s before removing it", ns)
+			finished = false
+			continue
+		}
+		// check whether the backup has finished
+		if completed, err := r.isBackupCompleted(aerospikeCluster, name); err != nil {
+			if err == errors.ClusterBackupFailed {
+				r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNamespaceRemovalBackupFailed,
+					"backup %s of namespace %s failed", name, ns)
+			}
+			return false, err
+		} else if !completed {
+			finished = false
+		}
+	}
+	return finished, nil
+}
+
+// getRemovedNamespaces returns the names of the namespaces that are present in
+// .status.namespaces but have been removed from .spec.namespaces.
+func getRemovedNamespaces(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []string {
+	var res []string
+	for _, old := range aerospikeCluster.Status.Namespaces {
+		found := false
+		for _, ns := range aerospikeCluster.Spec.Namespaces {
+			if ns.Name == old.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, old.Name)
+		}
+	}
+	return res
+}
+
+func (r *AerospikeClusterReconciler) createNamespaceBackup(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ns, name string) error {
 	backup := aerospikev1alpha2.AerospikeNamespaceBackup{
 		ObjectMeta: v1.ObjectMeta{
-			Name: GetBackupName(ns, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version),
+			Name: name,
 			Labels: map[string]string{
 				selectors.LabelAppKey:       selectors.LabelAppVal,
 				selectors.LabelClusterKey:   aerospikeCluster.Name,
@@ -92,37 +158,78 @@ func (r *AerospikeClusterReconciler) createNamespaceBackup(aerospikeCluster *aer
 		},
 	}
 
-	_, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(aerospikeCluster.Namespace).Create(context.TODO(), &backup, metav1.CreateOptions{})
+	_, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(aerospikeCluster.Namespace).Create(ctx, &backup, metav1.CreateOptions{})
+	if err == nil || !kubeerrors.IsAlreadyExists(err) {
+		return err
+	}
+	// the backup may have been created already in a previous (partially
+	// failed) attempt to backup the cluster, in which case we move on as long
+	// as it belongs to this cluster
+	existing, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(aerospikeCluster.Namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
-	return nil
+	return checkNamespaceBackupOwner(aerospikeCluster, existing)
 }
 
-func (r *AerospikeClusterReconciler) isBackupCompleted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ns string) (bool, error) {
+func (r *AerospikeClusterReconciler) isBackupCompleted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, name string) (bool, error) {
 	// get the AerospikeNamespaceBackup resource
-	backup, err := r.aerospikeBackupsLister.AerospikeNamespaceBackups(aerospikeCluster.Namespace).Get(GetBackupName(ns, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version))
+	backup, err := r.aerospikeBackupsLister.AerospikeNamespaceBackups(aerospikeCluster.Namespace).Get(name)
 	if err != nil {
 		return false, err
 	}
+	if err := checkNamespaceBackupOwner(aerospikeCluster, backup); err != nil {
+		return false, err
+	}
 
 	// look for ConditionBackupFinished
-	for _, condition := range backup.Status.Conditions {
-		if condition.Type == common.ConditionBackupFinished &&
-			condition.Status == apiextensions.ConditionTrue {
-			return true, nil
-		} else if condition.Type == common.ConditionBackupFailed &&
-			condition.Status == apiextensions.ConditionTrue {
-			return false, errors.ClusterBackupFailed
-		}
+	if apimeta.IsStatusConditionTrue(backup.Status.Conditions, common.ConditionBackupFinished) {
+		return true, nil
+	}
+	if apimeta.IsStatusConditionTrue(backup.Status.Conditions, common.ConditionBackupFailed) {
+		return false, errors.ClusterBackupFailed
 	}
 	return false, nil
 }
 
-// GetBackupName returns the name of a backup created automatically before upgrading
-func GetBackupName(ns, sourceVersion, targetVersion string) string {
-	return fmt.Sprintf("%s-%s-%s-upgrade", ns,
+// checkNamespaceBackupOwner makes sure that the specified backup has been
+// created for the specified cluster, and not for a different cluster whose
+// backups happen to have the same name.
+func checkNamespaceBackupOwner(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, backup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
+	if !metav1.IsControlledBy(backup, aerospikeCluster) {
+		return fmt.Errorf("aerospikenamespacebackup %s is not owned by aerospikecluster %s", meta.Key(backup), meta.Key(aerospikeCluster))
+	}
+	return nil
+}
+
+// GetBackupName returns the name of a backup created automatically before upgrading the specified cluster, truncating
+// the name of the cluster as required.
+func GetBackupName(clusterName, ns, sourceVersion, targetVersion string) string {
+	return namespaceBackupName(clusterName, fmt.Sprintf("-%s-%s-%s-upgrade", ns,
 		strings.Replace(sourceVersion, ".", "", -1),
 		strings.Replace(targetVersion, ".", "", -1),
-	)
+	))
+}
+
+// GetNamespaceRemovalBackupName returns the name of a backup created automatically before removing a namespace from
+// the specified cluster, truncating the name of the cluster as required.
+func GetNamespaceRemovalBackupName(clusterName, ns string, observedGeneration int64) string {
+	return namespaceBackupName(clusterName, fmt.Sprintf("-%s-removal-%d", ns, observedGeneration))
+}
+
+// namespaceBackupName returns the name of the cluster followed by suffix, truncating the name of the cluster so that
+// the result is a valid name for an AerospikeNamespaceBackup. A truncated name is followed by a short hash of the full
+// name, so that clusters whose names share the same prefix don't end up with the same backup names.
+func namespaceBackupName(clusterName, suffix string) string {
+	if len(clusterName)+len(suffix) <= namespaceBackupNameMaxLength {
+		return clusterName + suffix
+	}
+	hash := asstrings.Hash(clusterName)[:namespaceBackupNameHashLength]
+	if n := namespaceBackupNameMaxLength - len(suffix) - len(hash) - 1; n > 0 {
+		return clusterName[:n] + "-" + hash + suffix
+	}
+	if n := namespaceBackupNameMaxLength - len(suffix); n < len(hash) {
+		hash = hash[:n]
+	}
+	return hash + suffix
 }
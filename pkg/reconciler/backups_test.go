@@ -0,0 +1,44 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceBackupNameIsNotTruncatedIfShort(t *testing.T) {
+	assert.Equal(t, "as-cluster-0-test-4505-4510-upgrade", GetBackupName("as-cluster-0", "test", "4.5.0.5", "4.5.1.0"))
+	assert.Equal(t, "as-cluster-0-test-removal-3", GetNamespaceRemovalBackupName("as-cluster-0", "test", 3))
+}
+
+func TestNamespaceBackupNameIsUniqueWhenTruncated(t *testing.T) {
+	prefix := strings.Repeat("a", 50)
+	name1 := GetBackupName(prefix+"-cluster-1", "test", "4.5.0.5", "4.5.1.0")
+	name2 := GetBackupName(prefix+"-cluster-2", "test", "4.5.0.5", "4.5.1.0")
+	assert.NotEqual(t, name1, name2)
+	assert.True(t, len(name1) <= namespaceBackupNameMaxLength)
+	assert.True(t, len(name2) <= namespaceBackupNameMaxLength)
+	assert.True(t, strings.HasSuffix(name1, "-test-4505-4510-upgrade"))
+
+	name1 = GetNamespaceRemovalBackupName(prefix+"-cluster-1", strings.Repeat("n", 23), 1234567890)
+	name2 = GetNamespaceRemovalBackupName(prefix+"-cluster-2", strings.Repeat("n", 23), 1234567890)
+	assert.NotEqual(t, name1, name2)
+	assert.True(t, len(name1) <= namespaceBackupNameMaxLength)
+}
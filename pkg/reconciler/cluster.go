@@ -17,24 +17,34 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 	storagelistersv1 "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/client-go/tools/record"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	aerospikeclientset "github.com/travelaudience/aerospike-operator/pkg/client/clientset/versioned"
 	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/errors"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 type AerospikeClusterReconciler struct {
 	kubeclientset          kubernetes.Interface
 	aerospikeclientset     aerospikeclientset.Interface
+	dynamicclientset       dynamic.Interface
 	podsLister             listersv1.PodLister
 	configMapsLister       listersv1.ConfigMapLister
 	servicesLister         listersv1.ServiceLister
@@ -42,10 +52,16 @@ type AerospikeClusterReconciler struct {
 	scsLister              storagelistersv1.StorageClassLister
 	aerospikeBackupsLister aerospikelisters.AerospikeNamespaceBackupLister
 	recorder               record.EventRecorder
+
+	// the cached result of the last check for the prometheus operator crds
+	prometheusOperatorMutex     sync.Mutex
+	prometheusOperatorInstalled bool
+	prometheusOperatorCheckedAt time.Time
 }
 
 func New(kubeclientset kubernetes.Interface,
 	aerospikeclientset aerospikeclientset.Interface,
+	dynamicclientset dynamic.Interface,
 	podsLister listersv1.PodLister,
 	configMapsLister listersv1.ConfigMapLister,
 	servicesLister listersv1.ServiceLister,
@@ -56,6 +72,7 @@ func New(kubeclientset kubernetes.Interface,
 	return &AerospikeClusterReconciler{
 		kubeclientset:          kubeclientset,
 		aerospikeclientset:     aerospikeclientset,
+		dynamicclientset:       dynamicclientset,
 		podsLister:             podsLister,
 		configMapsLister:       configMapsLister,
 		servicesLister:         servicesLister,
@@ -67,11 +84,45 @@ func New(kubeclientset kubernetes.Interface,
 }
 
 // MaybeReconcile checks if reconciliation is needed.
-func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+func (r *AerospikeClusterReconciler) MaybeReconcile(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
 	}).Info("processing cluster")
 
+	// record whether reconciliation has just been paused or resumed
+	if paused := aerospikeCluster.Spec.Paused; paused != apimeta.IsStatusConditionTrue(aerospikeCluster.Status.Conditions, common.ConditionPaused) {
+		if paused {
+			r.recorder.Event(aerospikeCluster, v1.EventTypeNormal, events.ReasonReconciliationPaused, "reconciliation paused")
+		} else {
+			r.recorder.Event(aerospikeCluster, v1.EventTypeNormal, events.ReasonReconciliationResumed, "reconciliation resumed")
+		}
+	}
+
+	var err error
+	if aerospikeCluster.Spec.Paused {
+		// make no changes to the cluster, but keep reporting its status
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Info("reconciliation is paused")
+	} else {
+		err = r.reconcile(ctx, aerospikeCluster)
+	}
+	// report the observed state of the cluster regardless of the outcome, as
+	// long as we haven't been asked to stop
+	if ctx.Err() == nil {
+		if err := r.updateObservedStatus(ctx, aerospikeCluster, err); err != nil {
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			}).Warnf("failed to update observed status: %v", err)
+		}
+	}
+	return err
+}
+
+// reconcile brings the current state of the cluster closer to the desired
+// one.
+func (r *AerospikeClusterReconciler) reconcile(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+
 	// check if a previous upgrade operation has failed, in which case we return
 	if v, ok := aerospikeCluster.ObjectMeta.Annotations[UpgradeStatusAnnotationKey]; ok {
 		if v == UpgradeStatusFailedAnnotationValue {
@@ -106,20 +157,28 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 		// start the backup if no annotation is present
 		if status, ok := aerospikeCluster.Annotations[UpgradeStatusAnnotationKey]; !ok {
 			var err error
-			if aerospikeCluster, err = r.signalBackupStarted(aerospikeCluster); err != nil {
+			if aerospikeCluster, err = r.signalBackupStarted(ctx, aerospikeCluster); err != nil {
+				return err
+			}
+			if err := r.backupCluster(ctx, aerospikeCluster); err != nil {
 				return err
 			}
-			return r.backupCluster(aerospikeCluster)
+			return errors.NewRequeueError(backupsPollPeriod, "waiting for backups to finish before upgrading")
 		} else if status == UpgradeStatusBackupAnnotationValue {
+			// make sure a backup exists for every namespace, as a previous
+			// attempt to create them may have failed halfway through
+			if err := r.backupCluster(ctx, aerospikeCluster); err != nil {
+				return err
+			}
 			// check if autobackups have finished
 			if backupsCompleted, err := r.isClusterBackupFinished(aerospikeCluster); err != nil {
 				// if a backup failed, signal with the appropriate annotations
 				// and conditions
 				if err == errors.ClusterBackupFailed {
-					if _, err := r.signalBackupFailed(aerospikeCluster); err != nil {
+					if _, err := r.signalBackupFailed(ctx, aerospikeCluster); err != nil {
 						log.Errorf("failed to signal failed pre-upgrade backups: %v", err)
 					}
-					if _, err := r.signalUpgradeFailed(aerospikeCluster, upgrade); err != nil {
+					if _, err := r.signalUpgradeFailed(ctx, aerospikeCluster, upgrade); err != nil {
 						log.Errorf("failed to signal failed upgrade: %v", err)
 					}
 				}
@@ -128,53 +187,70 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 
 			} else if backupsCompleted {
 				// set the appropriate annotations and conditions
-				if aerospikeCluster, err = r.signalBackupFinished(aerospikeCluster); err != nil {
+				if aerospikeCluster, err = r.signalBackupFinished(ctx, aerospikeCluster); err != nil {
 					return err
 				}
-				if aerospikeCluster, err = r.signalUpgradeStarted(aerospikeCluster, upgrade); err != nil {
+				if aerospikeCluster, err = r.signalUpgradeStarted(ctx, aerospikeCluster, upgrade); err != nil {
 					return err
 				}
 
 			} else {
 				// backups did not finish yet, we may quit for now
-				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-				}).Debug("waiting for backups to finish before upgrading")
-				return nil
+				return errors.NewRequeueError(backupsPollPeriod, "waiting for backups to finish before upgrading")
 			}
 		}
 	}
 
-	// validate fields that cannot be validated statically
-	valid, err := r.validate(aerospikeCluster)
-	if err != nil {
+	// validate fields that cannot be validated statically. if the resource is
+	// not valid, no reconciliation is performed and we may quit (the problem is
+	// reported in the Valid condition)
+	if err := r.validate(aerospikeCluster); err != nil {
+		r.recorder.Event(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError, err.Error())
+		return nil
+	}
+	// make a final backup of any namespaces removed from the spec before the
+	// pods are restarted without them
+	if finished, err := r.backupRemovedNamespaces(ctx, aerospikeCluster); err != nil {
 		return err
+	} else if !finished {
+		return errors.NewRequeueError(backupsPollPeriod, "waiting for backups of removed namespaces to finish")
 	}
-	// if the resource is not valid, no reconciliation is performed and we may quit
-	if !valid {
-		return nil
+	// signal the start of a change to the replication factor of any namespace
+	if changes := getReplicationFactorChanges(aerospikeCluster); len(changes) > 0 && !isReplicationFactorChangeInProgress(aerospikeCluster) {
+		var err error
+		if aerospikeCluster, err = r.signalReplicationFactorChangeStarted(ctx, aerospikeCluster, changes); err != nil {
+			return err
+		}
 	}
 	// create the service for the cluster
-	if err := r.ensureService(aerospikeCluster); err != nil {
+	if err := r.ensureService(ctx, aerospikeCluster); err != nil {
+		return err
+	}
+	// create/update the pod disruption budget for the cluster
+	if err := r.ensurePodDisruptionBudget(ctx, aerospikeCluster); err != nil {
 		return err
 	}
 	// create/get the configmap
-	configMap, err := r.ensureConfigMap(aerospikeCluster)
+	configMap, err := r.ensureConfigMap(ctx, aerospikeCluster)
 	if err != nil {
 		return err
 	}
 	// create the network policy
-	if err := r.ensureNetworkPolicy(aerospikeCluster); err != nil {
+	if err := r.ensureNetworkPolicy(ctx, aerospikeCluster); err != nil {
+		return err
+	}
+	// create the prometheus operator objects (if requested)
+	if err := r.ensurePrometheusOperatorObjects(ctx, aerospikeCluster); err != nil {
 		return err
 	}
 
 	oldCluster := aerospikeCluster.DeepCopy()
 	// make sure that pods are up-to-date with the spec
-	if err := r.ensurePods(aerospikeCluster, configMap, upgrade); err != nil {
+	if err := r.ensurePods(ctx, aerospikeCluster, configMap, upgrade); err != nil {
 		// if a pod upgrade failed, signal with the appropriate annotations
 		// and conditions
 		if err == errors.PodUpgradeFailed {
-			if _, err := r.signalUpgradeFailed(aerospikeCluster, upgrade); err != nil {
+			if _, err := r.signalUpgradeFailed(ctx, aerospikeCluster, upgrade); err != nil {
 				log.Errorf("failed to signal failed upgrade: %v", err)
 			}
 		}
@@ -187,13 +263,20 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 
 	// patch the cluster with the changes performed in the ensurePods and
 	// updateStatus
-	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
 		return err
 	}
 
 	// set the appropriate annotations and conditions if performing an upgrade
 	if upgrade != nil {
-		if _, err := r.signalUpgradeFinished(aerospikeCluster, upgrade); err != nil {
+		if _, err := r.signalUpgradeFinished(ctx, aerospikeCluster, upgrade); err != nil {
+			return err
+		}
+	}
+	// set the appropriate annotations and conditions if the replication factor
+	// of any namespace has been changed
+	if isReplicationFactorChangeInProgress(aerospikeCluster) {
+		if _, err := r.signalReplicationFactorChangeFinished(ctx, aerospikeCluster); err != nil {
 			return err
 		}
 	}
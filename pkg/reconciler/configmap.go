@@ -19,6 +19,8 @@ package reconciler
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -37,15 +39,15 @@ import (
 	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
 )
 
-func (r *AerospikeClusterReconciler) ensureConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*v1.ConfigMap, error) {
+func (r *AerospikeClusterReconciler) ensureConfigMap(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*v1.ConfigMap, error) {
 	// grab the desired configmap object
 	desiredConfigMap := buildConfigMap(aerospikeCluster)
 	// try to actually create the configmap resource
-	if createdConfigMap, err := r.kubeclientset.CoreV1().ConfigMaps(aerospikeCluster.Namespace).Create(context.TODO(), desiredConfigMap, metav1.CreateOptions{}); err != nil {
+	if createdConfigMap, err := r.kubeclientset.CoreV1().ConfigMaps(aerospikeCluster.Namespace).Create(ctx, desiredConfigMap, metav1.CreateOptions{}); err != nil {
 		if errors.IsAlreadyExists(err) {
 			// a configmap with the same name already exists, so we need to
 			// handle an update
-			return r.updateConfigMap(aerospikeCluster, desiredConfigMap)
+			return r.updateConfigMap(ctx, aerospikeCluster, desiredConfigMap)
 		}
 		return nil, err
 	} else {
@@ -58,7 +60,7 @@ func (r *AerospikeClusterReconciler) ensureConfigMap(aerospikeCluster *aerospike
 	}
 }
 
-func (r *AerospikeClusterReconciler) updateConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desiredConfigMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+func (r *AerospikeClusterReconciler) updateConfigMap(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desiredConfigMap *v1.ConfigMap) (*v1.ConfigMap, error) {
 	// get the current configmap resource
 	currentConfigMap, err := r.configMapsLister.ConfigMaps(aerospikeCluster.Namespace).Get(desiredConfigMap.Name)
 	if err != nil {
@@ -66,7 +68,8 @@ func (r *AerospikeClusterReconciler) updateConfigMap(aerospikeCluster *aerospike
 	}
 	// check whether the current configmap resource needs to be updated
 	outdated := asstrings.Hash(currentConfigMap.Data[configFileName]) != currentConfigMap.Annotations[configMapHashAnnotation] ||
-		desiredConfigMap.Annotations[configMapHashAnnotation] != currentConfigMap.Annotations[configMapHashAnnotation]
+		desiredConfigMap.Annotations[configMapHashAnnotation] != currentConfigMap.Annotations[configMapHashAnnotation] ||
+		desiredConfigMap.Annotations[staticConfigHashAnnotation] != currentConfigMap.Annotations[staticConfigHashAnnotation]
 	// if the configmap is up-to-date, we're good to go
 	if !outdated {
 		log.WithFields(log.Fields{
@@ -81,7 +84,7 @@ func (r *AerospikeClusterReconciler) updateConfigMap(aerospikeCluster *aerospike
 		logfields.ConfigMap:        desiredConfigMap.Name,
 	}).Debug("configmap exists but is outdated")
 	// update the existing configmap resource to match the desired state
-	if updatedConfigMap, err := r.kubeclientset.CoreV1().ConfigMaps(aerospikeCluster.Namespace).Update(context.TODO(), desiredConfigMap, metav1.UpdateOptions{}); err != nil {
+	if updatedConfigMap, err := r.kubeclientset.CoreV1().ConfigMaps(aerospikeCluster.Namespace).Update(ctx, desiredConfigMap, metav1.UpdateOptions{}); err != nil {
 		return nil, err
 	} else {
 		log.WithFields(log.Fields{
@@ -92,24 +95,32 @@ func (r *AerospikeClusterReconciler) updateConfigMap(aerospikeCluster *aerospike
 	}
 }
 
-func buildConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+// buildConfig renders the aerospike config file for the specified cluster. if
+// maskDynamic is true, the values of dynamic configuration properties are
+// replaced by a placeholder, so that the result only changes when a restart
+// is required for the changes to be applied.
+func buildConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, maskDynamic bool) string {
 	var namespacesConfig []string
 
 	for index, namespace := range aerospikeCluster.Spec.Namespaces {
 		buf := new(bytes.Buffer)
-		asNamespaceTemplate.Execute(buf, getNamespaceProps(aerospikeCluster, index, &namespace))
+		asNamespaceTemplate.Execute(buf, getNamespaceProps(aerospikeCluster, index, &namespace, maskDynamic))
 		namespacesConfig = append(namespacesConfig, buf.String())
 	}
 
 	configMapBuffer := new(bytes.Buffer)
-	asConfigTemplate.Execute(configMapBuffer, getClusterProps(aerospikeCluster, namespacesConfig))
+	asConfigTemplate.Execute(configMapBuffer, getClusterProps(aerospikeCluster, namespacesConfig, maskDynamic))
 
 	return configMapBuffer.String()
 }
 
 func buildConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *v1.ConfigMap {
 	// build the aerospike config file based on the current spec
-	aerospikeConfig := buildConfig(aerospikeCluster)
+	aerospikeConfig := buildConfig(aerospikeCluster, false)
+	// build the aerospike config file disregarding dynamic properties. the
+	// storage size of every namespace is taken into account as well, since
+	// aerospike must be restarted in order to use an expanded device.
+	staticConfig := buildConfig(aerospikeCluster, true) + storageSizes(aerospikeCluster)
 	// return a configmap object containing aerospikeConfig
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -130,22 +141,59 @@ func buildConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *v1.Co
 				},
 			},
 			Annotations: map[string]string{
-				configMapHashAnnotation: asstrings.Hash(aerospikeConfig),
+				configMapHashAnnotation:    asstrings.Hash(aerospikeConfig),
+				staticConfigHashAnnotation: asstrings.Hash(staticConfig),
 			},
 		},
 		Data: map[string]string{configFileName: aerospikeConfig},
 	}
 }
 
-func getClusterProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespacesConfig []string) map[string]interface{} {
+func getClusterProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespacesConfig []string, maskDynamic bool) map[string]interface{} {
+	var serviceConfig, networkConfig map[string]string
+	if aerospikeCluster.Spec.AerospikeConfig != nil {
+		serviceConfig = aerospikeCluster.Spec.AerospikeConfig.Service
+		networkConfig = aerospikeCluster.Spec.AerospikeConfig.Network
+	}
+	// merge the user-provided properties into the defaults
+	service, serviceExtra := mergeConfigProps(defaultServiceConfig, serviceConfig)
+	network, networkExtra := mergeConfigProps(defaultNetworkConfig, networkConfig)
+	if maskDynamic {
+		service = maskConfigProps(service, dynamicServiceConfig)
+		serviceExtra = maskConfigProps(serviceExtra, dynamicServiceConfig)
+		network = maskConfigProps(network, dynamicNetworkConfig)
+		networkExtra = maskConfigProps(networkExtra, dynamicNetworkConfig)
+	}
+	// group the remaining network properties by the sub-stanza they belong to
+	networkExtraBySubStanza := make(map[string]map[string]string)
+	for key, value := range networkExtra {
+		parts := strings.SplitN(key, ".", 2)
+		if len(parts) != 2 {
+			// should not happen, as keys are validated by the admission webhook
+			continue
+		}
+		if _, ok := networkExtraBySubStanza[parts[0]]; !ok {
+			networkExtraBySubStanza[parts[0]] = make(map[string]string)
+		}
+		networkExtraBySubStanza[parts[0]][parts[1]] = value
+	}
+	networkExtraLines := make(map[string][]string, len(networkExtraBySubStanza))
+	for subStanza, props := range networkExtraBySubStanza {
+		networkExtraLines[subStanza] = configLines(props)
+	}
+
 	return map[string]interface{}{
 		serviceNodeIdKey:            ServiceNodeIdValue,
 		clusterNamespacesKey:        namespacesConfig,
 		heartbeatAddressesConfigKey: HeartbeatAddressesValue,
+		serviceConfigKey:            service,
+		serviceExtraConfigKey:       configLines(serviceExtra),
+		networkConfigKey:            network,
+		networkExtraConfigKey:       networkExtraLines,
 	}
 }
 
-func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) map[string]interface{} {
+func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int, namespace *aerospikev1alpha2.AerospikeNamespaceSpec, maskDynamic bool) map[string]interface{} {
 	props := make(map[string]interface{})
 
 	props[nsNameKey] = namespace.Name
@@ -158,6 +206,11 @@ func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ind
 		}
 	}
 
+	// the rack-id depends on the pod, and is set by asinit
+	if len(aerospikeCluster.Spec.Racks) > 0 {
+		props[nsRackIdKey] = NamespaceRackIdValue
+	}
+
 	if namespace.MemorySize != nil && *namespace.MemorySize != "" {
 		props[nsMemorySizeKey] = namespace.MemorySize
 	} else {
@@ -165,10 +218,10 @@ func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ind
 		props[nsMemorySizeKey] = defaultMemorySize
 	}
 
-	if namespace.DefaultTTL != nil {
-		if value, err := strconv.Atoi(strings.TrimSuffix(*namespace.DefaultTTL, "s")); err == nil {
-			props[nsDefaultTTLKey] = value
-		}
+	// default-ttl is left out of the static config altogether, as both
+	// setting and unsetting it can be done at runtime
+	if value, ok := getDefaultTTL(namespace); ok && !maskDynamic {
+		props[nsDefaultTTLKey] = value
 	}
 
 	props[nsStorageTypeKey] = namespace.Storage.Type
@@ -184,5 +237,114 @@ func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ind
 		props[nsDataInMemory] = *namespace.Storage.DataInMemory
 	}
 
+	// split the user-provided properties between the namespace stanza and
+	// the storage-engine sub-stanza
+	if namespaceConfig := getNamespaceConfig(aerospikeCluster, namespace); len(namespaceConfig) > 0 {
+		namespaceExtra := make(map[string]string)
+		storageEngineExtra := make(map[string]string)
+		for key, value := range namespaceConfig {
+			if strings.HasPrefix(key, storageEngineConfigPrefix) {
+				storageEngineExtra[strings.TrimPrefix(key, storageEngineConfigPrefix)] = value
+			} else {
+				namespaceExtra[key] = value
+			}
+		}
+		if maskDynamic {
+			namespaceExtra = maskConfigProps(namespaceExtra, dynamicNamespaceConfig)
+		}
+		props[nsExtraKey] = configLines(namespaceExtra)
+		props[nsStorageEngineExtraKey] = configLines(storageEngineExtra)
+	}
+
 	return props
 }
+
+// getNamespaceConfig returns the user-provided properties for the stanza of
+// the specified namespace, i.e. the properties common to every namespace
+// overridden by the ones specific to the namespace.
+func getNamespaceConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) map[string]string {
+	var shared map[string]string
+	if aerospikeCluster.Spec.AerospikeConfig != nil {
+		shared = aerospikeCluster.Spec.AerospikeConfig.Namespace
+	}
+	res := make(map[string]string, len(shared)+len(namespace.AerospikeConfig))
+	for key, value := range shared {
+		res[key] = value
+	}
+	for key, value := range namespace.AerospikeConfig {
+		res[key] = value
+	}
+	return res
+}
+
+// mergeConfigProps overrides the specified defaults with the user-provided
+// properties. properties that do not correspond to a default are returned
+// separately.
+func mergeConfigProps(defaults, props map[string]string) (map[string]string, map[string]string) {
+	merged := make(map[string]string, len(defaults))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	extra := make(map[string]string)
+	for key, value := range props {
+		if _, ok := defaults[key]; ok {
+			merged[key] = value
+		} else {
+			extra[key] = value
+		}
+	}
+	return merged, extra
+}
+
+// storageSizes returns a string describing the storage size of every namespace
+// in the specified cluster.
+func storageSizes(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	var res []string
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		res = append(res, fmt.Sprintf("%s=%s", namespace.Name, namespace.Storage.Size))
+	}
+	return strings.Join(res, ",")
+}
+
+// getDefaultTTL returns the value of default-ttl for the specified namespace in
+// seconds, and whether it has been set.
+func getDefaultTTL(namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (int, bool) {
+	if namespace.DefaultTTL == nil {
+		return 0, false
+	}
+	value, err := strconv.Atoi(strings.TrimSuffix(*namespace.DefaultTTL, "s"))
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// maskConfigProps returns a copy of the specified properties in which the
+// values of the properties present in dynamic are replaced by a placeholder.
+func maskConfigProps(props map[string]string, dynamic map[string]bool) map[string]string {
+	res := make(map[string]string, len(props))
+	for key, value := range props {
+		if dynamic[key] {
+			res[key] = dynamicConfigPlaceholder
+		} else {
+			res[key] = value
+		}
+	}
+	return res
+}
+
+// configLines returns the specified properties as a list of lines in the
+// format expected by aerospike.conf, sorted by key so that the resulting
+// config (and its hash) is stable.
+func configLines(props map[string]string) []string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s %s", key, props[key]))
+	}
+	return lines
+}
@@ -19,50 +19,102 @@ package reconciler
 import (
 	"text/template"
 	"time"
+
+	"github.com/travelaudience/aerospike-operator/pkg/utils/reserved"
 )
 
 const (
 	// the name of the volume that will contain the original aerospike.conf
 	// created as a result of mounting the configmap (i.e. before templating)
-	initialConfigVolumeName = "aerospike-conf-src"
+	initialConfigVolumeName = reserved.InitialConfigVolumeName
 	// the mount path of the volume that will contain the initial
 	// aerospike.conf created as a result of mounting the configmap
 	initialConfigMountPath = "/aerospike-conf-src"
 	// the name of the volume that will contain the final aerospike.conf file
 	// (i.e. after templating)
-	finalConfigVolumeName = "aerospike-conf"
+	finalConfigVolumeName = reserved.FinalConfigVolumeName
 	// the mount path of the volume that will contain the final aerospike.conf
 	// file (i.e. after templating)
 	finalConfigMountPath = "/aerospike-conf"
 	// the name of the aerospike.conf file
 	configFileName = "aerospike.conf"
 
-	namespaceVolumePrefix = "data-ns"
+	namespaceVolumePrefix = reserved.NamespaceVolumePrefix
+
+	// the names of the containers managed by aerospike-operator
+	initContainerName            = reserved.InitContainerName
+	aerospikeServerContainerName = reserved.AerospikeServerContainerName
+	aspromContainerName          = reserved.AspromContainerName
 
-	ServicePort       = 3000
+	ServicePort       = reserved.ServicePort
 	servicePortName   = "service"
-	HeartbeatPort     = 3002
+	HeartbeatPort     = reserved.HeartbeatPort
 	heartbeatPortName = "heartbeat"
-	fabricPort        = 3001
+	fabricPort        = reserved.FabricPort
 	fabricPortName    = "fabric"
-	infoPort          = 3003
+	infoPort          = reserved.InfoPort
 	infoPortName      = "info"
 
-	watchCreatePodTimeout  = 3 * time.Hour
-	watchDeletePodTimeout  = 3 * time.Minute
+	// createPodTimeout is how long we will wait for a new pod to be running
+	// and ready before re-creating it
+	createPodTimeout = 3 * time.Hour
+	// deletePodTimeout is how long we will wait for a deleted pod to be gone
+	deletePodTimeout       = 3 * time.Minute
 	terminationGracePeriod = 2 * time.Minute
-	waitMigrationsTimeout  = 1 * time.Hour
+	// waitStabilizationTimeout is how long we will wait by default for the
+	// cluster to become stable before operating on the next pod
+	waitStabilizationTimeout = 1 * time.Hour
+	// waitQuiesceTimeout is how long we will wait by default for a quiesced
+	// node to hand off its master partitions before deleting its pod
+	waitQuiesceTimeout = 1 * time.Hour
+	// quiesceBackoffPeriod is how long we will wait before quiescing a node
+	// again after it failed to hand off its master partitions in time. the
+	// period doubles with every failure, up to maxQuiesceBackoffPeriod.
+	quiesceBackoffPeriod    = 5 * time.Minute
+	maxQuiesceBackoffPeriod = 1 * time.Hour
+	// migrationsPollPeriod is the minimum amount of time to wait before
+	// checking again whether migrations have finished
+	migrationsPollPeriod = 10 * time.Second
+	// backupsPollPeriod is the minimum amount of time to wait before checking
+	// again whether backups have finished
+	backupsPollPeriod = 30 * time.Second
 	// waitClusterSizeTimeout is how long we will wait for a new pod to report
 	// the correct cluster size before forcibly deleting it
 	waitClusterSizeTimeout = 1 * time.Minute
-
-	podOperationFeedbackPeriod = 2 * time.Minute
-	aerospikeClientTimeout     = 10 * time.Second
+	// waitPVCResizeTimeout is how long we will wait for a persistent volume
+	// claim to be resized after requesting its expansion
+	waitPVCResizeTimeout = 10 * time.Minute
+	// pvcResizePollPeriod is the minimum amount of time to wait before
+	// checking again whether a persistent volume claim has been resized
+	pvcResizePollPeriod = 10 * time.Second
+	// namespaceBackupNameMaxLength is the maximum length of the name of a
+	// backup created by aerospike-operator, so that the name of the
+	// corresponding job (suffixed by "-backup") does not exceed 63 characters
+	namespaceBackupNameMaxLength = 56
+	// namespaceBackupNameHashLength is the length of the hash of the name of
+	// the cluster appended to the name of a backup when the former is truncated
+	namespaceBackupNameHashLength = 8
 
 	// the name of the annotation that holds the hash of the mounted configmap
 	configMapHashAnnotation = "aerospike.travelaudience.com/config-map-hash"
+	// the name of the annotation that holds the hash of the mounted configmap
+	// disregarding the values of dynamic configuration properties
+	staticConfigHashAnnotation = "aerospike.travelaudience.com/static-config-hash"
+	// the name of the annotation that holds the hash of the settings used to
+	// generate the spec of a pod
+	podSpecHashAnnotation = "aerospike.travelaudience.com/pod-spec-hash"
+	// the name of the annotation that holds the value of
+	// .spec.restartGeneration at the time a pod was created
+	restartGenerationAnnotation = "aerospike.travelaudience.com/restart-generation"
+	// ReplaceAnnotation is the name of the annotation that, when set to "true"
+	// on a pod, requests the pod to be re-created with new persistent volume
+	// claims
+	ReplaceAnnotation = "aerospike.travelaudience.com/replace"
 	// the name of the annotation that holds the aerospike node id
 	nodeIdAnnotation = "aerospike.travelaudience.com/node-id"
+	// the name of the annotation that holds the id of the rack the aerospike
+	// node belongs to
+	rackIdAnnotation = "aerospike.travelaudience.com/rack-id"
 	// the name of the annotation that holds the name of the pod with which a
 	// PVC is associated
 	PodAnnotation = "aerospike.travelaudience.com/pod-name"
@@ -72,6 +124,15 @@ const (
 	// the name of the annotation that holds the timestamp at which a PVC
 	// was last unmounted from a pod
 	LastUnmountedOnAnnotation = "aerospike.travelaudience.com/last-unmounted-on"
+	// the name of the annotation that holds the timestamp at which the pod a
+	// PVC was mounted on was replaced, in which case the PVC is not reused
+	ReplacedOnAnnotation = "aerospike.travelaudience.com/replaced-on"
+	// the name of the annotation that holds the name of the storage class
+	// requested when a PVC was created (empty for the default storage class)
+	requestedStorageClassAnnotation = "aerospike.travelaudience.com/requested-storage-class"
+	// the name of the annotation that holds the timestamp at which the
+	// expansion of a PVC was requested
+	expansionRequestedOnAnnotation = "aerospike.travelaudience.com/expansion-requested-on"
 
 	// the name of the key that corresponds to the service.node-id property
 	// (used for templating)
@@ -82,6 +143,9 @@ const (
 	clusterNamespacesKey        = "namespaces"
 	heartbeatAddressesConfigKey = "heartbeatAddresses"
 	HeartbeatAddressesValue     = "__NETWORK__HEARTBEAT__MESH_SEED_ADDRESS_PORT__"
+	// the value of the key that corresponds to the namespace.rack-id property
+	// (used for templating)
+	NamespaceRackIdValue = "__NAMESPACE__RACK_ID__"
 
 	defaultFilePath         = "/opt/aerospike/data/"
 	defaultDevicePathPrefix = "/dev/xvd"
@@ -95,13 +159,27 @@ const (
 	nsFilePath             = "filePath"
 	nsDevicePath           = "devicePath"
 	nsDataInMemory         = "dataInMemory"
-
-	aspromPortName      = "prometheus"
-	aspromPort          = 9145
-	aspromCpuRequest    = "10m"
-	aspromMemoryRequest = "32Mi"
-	aspromCpuLimit      = "20m"
-	aspromMemoryLimit   = "128Mi"
+	nsRackIdKey            = "rackId"
+
+	// the names of the keys that hold the user-provided properties of the
+	// service, network and namespace stanzas (used for templating)
+	nsExtraKey              = "namespaceExtra"
+	nsStorageEngineExtraKey = "storageEngineExtra"
+	serviceConfigKey        = "service"
+	serviceExtraConfigKey   = "serviceExtra"
+	networkConfigKey        = "network"
+	networkExtraConfigKey   = "networkExtra"
+	// the prefix of the properties of the storage-engine sub-stanza of a
+	// namespace in .spec.aerospikeConfig.namespace
+	storageEngineConfigPrefix = "storage-engine."
+
+	aspromPortName          = "prometheus"
+	aspromContainerPortName = "http"
+	aspromPort              = 9145
+	aspromCpuRequest        = "10m"
+	aspromMemoryRequest     = "32Mi"
+	aspromCpuLimit          = "20m"
+	aspromMemoryLimit       = "128Mi"
 
 	asReadinessInitialDelaySeconds = 3
 	asReadinessTimeoutSeconds      = 2
@@ -131,6 +209,14 @@ const (
 	// UpgradeStatusBackupAnnotationValue is the value of the annotation added
 	// to AerospikeCluster resources that are undergoing a pre-upgrade backup.
 	UpgradeStatusBackupAnnotationValue = "backup"
+	// ReplicationFactorChangeAnnotationKey is the name of the annotation
+	// added to AerospikeCluster resources while the replication factor of
+	// any of their namespaces is being changed.
+	ReplicationFactorChangeAnnotationKey = "aerospike.travelaudience.com/replication-factor-change"
+	// ReplicationFactorChangeStartedAnnotationValue is the value of the
+	// annotation added to AerospikeCluster resources while the replication
+	// factor of any of their namespaces is being changed.
+	ReplicationFactorChangeStartedAnnotationValue = "started"
 
 	// terminal state reasons when pod status is Pending
 	// container image pull failed
@@ -150,20 +236,84 @@ const (
 	defaultMemorySize = "4G"
 )
 
+// defaultServiceConfig holds the default values of the properties of the
+// service stanza that can be overridden in .spec.aerospikeConfig.service
+var defaultServiceConfig = map[string]string{
+	"user":                          "root",
+	"group":                         "root",
+	"paxos-single-replica-limit":    "1",
+	"pidfile":                       "/var/run/aerospike/asd.pid",
+	"service-threads":               "4",
+	"transaction-queues":            "4",
+	"transaction-threads-per-queue": "4",
+	"proto-fd-max":                  "15000",
+}
+
+// defaultNetworkConfig holds the default values of the properties of the
+// network stanza that can be overridden in .spec.aerospikeConfig.network
+var defaultNetworkConfig = map[string]string{
+	"heartbeat.interval": "100",
+	"heartbeat.timeout":  "10",
+}
+
+// dynamicConfigPlaceholder is the value used in place of the values of
+// dynamic configuration properties when computing the static config hash
+const dynamicConfigPlaceholder = "<dynamic>"
+
+// dynamicServiceConfig holds the properties of the service stanza that can be
+// changed at runtime using set-config
+// https://www.aerospike.com/docs/reference/configuration/
+var dynamicServiceConfig = map[string]bool{
+	"batch-index-threads":           true,
+	"migrate-max-num-incoming":      true,
+	"migrate-threads":               true,
+	"proto-fd-idle-ms":              true,
+	"proto-fd-max":                  true,
+	"query-threads":                 true,
+	"ticker-interval":               true,
+	"transaction-max-ms":            true,
+	"transaction-retry-ms":          true,
+	"transaction-threads-per-queue": true,
+}
+
+// dynamicNetworkConfig holds the properties of the network stanza that can be
+// changed at runtime using set-config
+var dynamicNetworkConfig = map[string]bool{
+	"heartbeat.interval": true,
+	"heartbeat.timeout":  true,
+}
+
+// dynamicNamespaceConfig holds the properties of the namespace stanza that can
+// be changed at runtime using set-config
+var dynamicNamespaceConfig = map[string]bool{
+	"default-ttl":           true,
+	"disable-write-dup-res": true,
+	"evict-tenths-pct":      true,
+	"high-water-disk-pct":   true,
+	"high-water-memory-pct": true,
+	"migrate-order":         true,
+	"migrate-sleep":         true,
+	"nsup-period":           true,
+	"stop-writes-pct":       true,
+}
+
 var asConfigTemplate = template.Must(template.New("aerospike-config").Parse(aerospikeConfig))
 var asNamespaceTemplate = template.Must(template.New("as-namespace-config").Parse(aerospikeNamespaceConfig))
 
 const aerospikeConfig = `
 service {
-	user root
-	group root
-	paxos-single-replica-limit 1
-	pidfile /var/run/aerospike/asd.pid
-	service-threads 4
-	transaction-queues 4
-	transaction-threads-per-queue 4
-	proto-fd-max 15000
+	user {{index .service "user"}}
+	group {{index .service "group"}}
+	paxos-single-replica-limit {{index .service "paxos-single-replica-limit"}}
+	pidfile {{index .service "pidfile"}}
+	service-threads {{index .service "service-threads"}}
+	transaction-queues {{index .service "transaction-queues"}}
+	transaction-threads-per-queue {{index .service "transaction-threads-per-queue"}}
+	proto-fd-max {{index .service "proto-fd-max"}}
 	node-id {{.nodeId}}
+{{- range .serviceExtra}}
+	{{.}}
+{{- end}}
 }
 
 logging {
@@ -180,6 +330,9 @@ network {
 	service {
 		address any
 		port 3000
+{{- range index .networkExtra "service"}}
+		{{.}}
+{{- end}}
 	}
 
 	heartbeat {
@@ -188,16 +341,25 @@ network {
 
 		{{.heartbeatAddresses}}
 
-		interval 100
-		timeout 10
+		interval {{index .network "heartbeat.interval"}}
+		timeout {{index .network "heartbeat.timeout"}}
+{{- range index .networkExtra "heartbeat"}}
+		{{.}}
+{{- end}}
 	}
 
 	fabric {
 		port 3001
+{{- range index .networkExtra "fabric"}}
+		{{.}}
+{{- end}}
 	}
 
 	info {
 		port 3003
+{{- range index .networkExtra "info"}}
+		{{.}}
+{{- end}}
 	}
 }
 
@@ -213,6 +375,10 @@ namespace {{.name}} {
 		replication-factor {{.replicationFactor}}
 	{{end}}
 
+	{{if .rackId}}
+		rack-id {{.rackId}}
+	{{end}}
+
 	{{if .memorySize}}
 		memory-size {{.memorySize}}
 	{{end}}
@@ -220,6 +386,9 @@ namespace {{.name}} {
 	{{if .defaultTTL}}
 		default-ttl {{.defaultTTL}}
 	{{end}}
+{{- range .namespaceExtra}}
+	{{.}}
+{{- end}}
 
 	storage-engine device {
 
@@ -236,5 +405,8 @@ namespace {{.name}} {
 		{{- if .dataInMemory}}
 			data-in-memory {{.dataInMemory}}
 		{{- end}}
+{{- range .storageEngineExtra}}
+		{{.}}
+{{- end}}
 	}
 }`
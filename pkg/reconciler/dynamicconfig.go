@@ -0,0 +1,174 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+)
+
+// podNeedsRestart returns whether the specified pod must be restarted in order
+// to pick up the changes made to the configmap, i.e. whether any static
+// configuration property has changed.
+func podNeedsRestart(configMap *corev1.ConfigMap, pod *corev1.Pod) bool {
+	// pods created by previous versions of aerospike-operator do not hold the
+	// static config hash, so we must rely on the full hash
+	if _, ok := pod.Annotations[staticConfigHashAnnotation]; !ok {
+		return configMap.Annotations[configMapHashAnnotation] != pod.Annotations[configMapHashAnnotation]
+	}
+	return configMap.Annotations[staticConfigHashAnnotation] != pod.Annotations[staticConfigHashAnnotation]
+}
+
+// applyDynamicConfigToPod uses set-config to apply the current values of the
+// dynamic configuration properties to the aerospike node running in the
+// specified pod, and then updates the pod's annotations to reflect the fact
+// that it is in sync with the configmap.
+func (r *AerospikeClusterReconciler) applyDynamicConfigToPod(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, pod *corev1.Pod) (*corev1.Pod, error) {
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.Pod:              meta.Key(pod),
+	}).Debug("applying dynamic configuration to pod")
+
+	dynamicConfig := getDynamicConfig(aerospikeCluster)
+	// iterate over contexts in a stable order
+	contexts := make([]string, 0, len(dynamicConfig))
+	for infoContext := range dynamicConfig {
+		contexts = append(contexts, infoContext)
+	}
+	sort.Strings(contexts)
+	for _, infoContext := range contexts {
+		// grab the values currently in use for the context
+		getCmd := fmt.Sprintf("get-config:context=%s", infoContext)
+		res, err := runInfoCommandOnPod(ctx, pod, getCmd)
+		if err != nil {
+			return nil, err
+		}
+		current := asutils.ParseStatistics(res[getCmd])
+		// set the properties whose values differ from the desired ones
+		props := dynamicConfig[infoContext]
+		keys := make([]string, 0, len(props))
+		for key := range props {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := props[key]
+			if current[key] == value {
+				continue
+			}
+			setCmd := fmt.Sprintf("set-config:context=%s;%s=%s", infoContext, key, value)
+			res, err := runInfoCommandOnPod(ctx, pod, setCmd)
+			if err != nil {
+				return nil, err
+			}
+			if res[setCmd] != "ok" {
+				return nil, fmt.Errorf("failed to set %s to %s in context %s on pod %s: %s", key, value, infoContext, meta.Key(pod), res[setCmd])
+			}
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+				logfields.Pod:              meta.Key(pod),
+			}).Debugf("set %s to %s in context %s", key, value, infoContext)
+		}
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeConfigUpdated,
+		"dynamic configuration applied to pod %s", meta.Key(pod))
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.Pod:              meta.Key(pod),
+	}).Infof("dynamic configuration applied to pod %s", meta.Key(pod))
+
+	// record the fact that the pod is now in sync with the configmap
+	newPod := pod.DeepCopy()
+	if newPod.Annotations == nil {
+		newPod.Annotations = make(map[string]string)
+	}
+	newPod.Annotations[configMapHashAnnotation] = configMap.Annotations[configMapHashAnnotation]
+	newPod.Annotations[staticConfigHashAnnotation] = configMap.Annotations[staticConfigHashAnnotation]
+	return r.patchPod(ctx, pod, newPod)
+}
+
+// getDynamicConfig returns the desired values of the dynamic configuration
+// properties for the specified cluster, indexed by the info context they
+// belong to.
+func getDynamicConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) map[string]map[string]string {
+	var serviceConfig, networkConfig map[string]string
+	if aerospikeCluster.Spec.AerospikeConfig != nil {
+		serviceConfig = aerospikeCluster.Spec.AerospikeConfig.Service
+		networkConfig = aerospikeCluster.Spec.AerospikeConfig.Network
+	}
+	service, serviceExtra := mergeConfigProps(defaultServiceConfig, serviceConfig)
+	network, networkExtra := mergeConfigProps(defaultNetworkConfig, networkConfig)
+
+	res := make(map[string]map[string]string)
+	res["service"] = filterConfigProps(dynamicServiceConfig, service, serviceExtra)
+	res["network"] = filterConfigProps(dynamicNetworkConfig, network, networkExtra)
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		props := filterConfigProps(dynamicNamespaceConfig, getNamespaceConfig(aerospikeCluster, &namespace))
+		// an unset default-ttl corresponds to aerospike's default of zero
+		ttl, _ := getDefaultTTL(&namespace)
+		props["default-ttl"] = strconv.Itoa(ttl)
+		res[fmt.Sprintf("namespace;id=%s", namespace.Name)] = props
+	}
+	return res
+}
+
+// filterConfigProps returns the properties present in the specified maps that
+// are also present in dynamic.
+func filterConfigProps(dynamic map[string]bool, props ...map[string]string) map[string]string {
+	res := make(map[string]string)
+	for _, p := range props {
+		for key, value := range p {
+			if dynamic[key] {
+				res[key] = value
+			}
+		}
+	}
+	return res
+}
+
+// patchPod patches the specified pod with the changes between old and new
+func (r *AerospikeClusterReconciler) patchPod(ctx context.Context, old, new *corev1.Pod) (*corev1.Pod, error) {
+	oldBytes, err := json.Marshal(old)
+	if err != nil {
+		return nil, err
+	}
+	newBytes, err := json.Marshal(new)
+	if err != nil {
+		return nil, err
+	}
+	patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldBytes, newBytes, &corev1.Pod{})
+	if err != nil {
+		return nil, err
+	}
+	return r.kubeclientset.CoreV1().Pods(old.Namespace).Patch(ctx, old.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
+)
+
+// isMonitoringEnabled returns whether the metrics exporter must run alongside
+// the aerospike nodes of the specified cluster.
+func isMonitoringEnabled(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) bool {
+	monitoring := aerospikeCluster.Spec.Monitoring
+	return monitoring == nil || monitoring.Enabled == nil || *monitoring.Enabled
+}
+
+// getMonitoringPort returns the port on which the metrics exporter of the
+// specified cluster serves metrics.
+func getMonitoringPort(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) int32 {
+	monitoring := aerospikeCluster.Spec.Monitoring
+	if monitoring == nil || monitoring.Port == nil {
+		return aspromPort
+	}
+	return *monitoring.Port
+}
+
+// computeExporterContainer returns the metrics exporter container for the pods
+// of the specified cluster. unless a different image is specified, asprom is
+// used as the metrics exporter.
+func computeExporterContainer(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) corev1.Container {
+	monitoring := aerospikeCluster.Spec.Monitoring
+	if monitoring == nil {
+		monitoring = &aerospikev1alpha2.MonitoringSpec{}
+	}
+	port := getMonitoringPort(aerospikeCluster)
+
+	container := corev1.Container{
+		Name:            aspromContainerName,
+		Image:           monitoring.Image,
+		ImagePullPolicy: images.PullPolicy(aerospikeCluster.Spec.Images, ""),
+		Args:            monitoring.Args,
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          aspromContainerPortName,
+				ContainerPort: port,
+			},
+		},
+		LivenessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/metrics",
+					Port: intstr.IntOrString{
+						IntVal: port,
+					},
+				},
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(aspromCpuRequest),
+				corev1.ResourceMemory: resource.MustParse(aspromMemoryRequest),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(aspromCpuLimit),
+				corev1.ResourceMemory: resource.MustParse(aspromMemoryLimit),
+			},
+		},
+	}
+	// run asprom from the tools image, making it listen on the requested port
+	if container.Image == "" {
+		container.Image = images.Tools(aerospikeCluster.Spec.Images)
+		container.Command = []string{
+			"asprom",
+			fmt.Sprintf("-listen=:%d", port),
+		}
+	}
+	if monitoring.Resources != nil {
+		container.Resources = *monitoring.Resources
+	}
+	return container
+}
@@ -18,6 +18,7 @@ package reconciler
 
 import (
 	"context"
+	"reflect"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
@@ -40,7 +41,7 @@ var (
 	protocolUDP = v1.ProtocolUDP
 )
 
-func (r *AerospikeClusterReconciler) ensureNetworkPolicy(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+func (r *AerospikeClusterReconciler) ensureNetworkPolicy(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	policy := networkv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: aerospikeCluster.Name,
@@ -112,12 +113,6 @@ func (r *AerospikeClusterReconciler) ensureNetworkPolicy(aerospikeCluster *aeros
 								IntVal: infoPort,
 							},
 						},
-						{
-							Protocol: &protocolTCP,
-							Port: &intstr.IntOrString{
-								IntVal: aspromPort,
-							},
-						},
 					},
 				},
 			},
@@ -168,14 +163,24 @@ func (r *AerospikeClusterReconciler) ensureNetworkPolicy(aerospikeCluster *aeros
 		},
 	}
 
-	if _, err := r.kubeclientset.NetworkingV1().NetworkPolicies(aerospikeCluster.Namespace).Create(context.TODO(), &policy, metav1.CreateOptions{}); err != nil {
+	// allow for the metrics exporter to be scraped
+	if isMonitoringEnabled(aerospikeCluster) {
+		policy.Spec.Ingress[1].Ports = append(policy.Spec.Ingress[1].Ports, networkv1.NetworkPolicyPort{
+			Protocol: &protocolTCP,
+			Port: &intstr.IntOrString{
+				IntVal: getMonitoringPort(aerospikeCluster),
+			},
+		})
+	}
+
+	if _, err := r.kubeclientset.NetworkingV1().NetworkPolicies(aerospikeCluster.Namespace).Create(ctx, &policy, metav1.CreateOptions{}); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return err
 		}
 		log.WithFields(log.Fields{
 			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
 		}).Debug("networkpolicy already exists")
-		return nil
+		return r.maybeUpdateNetworkPolicy(ctx, aerospikeCluster, &policy)
 	}
 
 	log.WithFields(log.Fields{
@@ -183,3 +188,25 @@ func (r *AerospikeClusterReconciler) ensureNetworkPolicy(aerospikeCluster *aeros
 	}).Debug("networkpolicy created")
 	return nil
 }
+
+// maybeUpdateNetworkPolicy updates the existing network policy for the cluster
+// if its ingress rules differ from the desired ones (e.g. because the port of
+// the metrics exporter has been changed).
+func (r *AerospikeClusterReconciler) maybeUpdateNetworkPolicy(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desired *networkv1.NetworkPolicy) error {
+	current, err := r.kubeclientset.NetworkingV1().NetworkPolicies(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(current.Spec.Ingress, desired.Spec.Ingress) {
+		return nil
+	}
+	policy := current.DeepCopy()
+	policy.Spec.Ingress = desired.Spec.Ingress
+	if _, err := r.kubeclientset.NetworkingV1().NetworkPolicies(policy.Namespace).Update(ctx, policy, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug("networkpolicy updated")
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+// computeDesiredPods returns the indexes of the pods that must exist in order
+// for the specified cluster to match its spec, mapped to the node group each
+// pod belongs to (or to nil if no node groups have been defined). existing
+// pods remain in the node group they belong to for as long as the group has
+// room for them, pods that don't belong to any node group yet are adopted by
+// the node groups that have room for them, and new pods are given the lowest indexes not in use by any
+// existing pod so that they can be created before the pods they replace are
+// deleted.
+func computeDesiredPods(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pods []*corev1.Pod) map[int]*aerospikev1alpha2.NodeGroupSpec {
+	res := make(map[int]*aerospikev1alpha2.NodeGroupSpec)
+	// without node groups, pods are given contiguous indexes
+	if len(aerospikeCluster.Spec.NodeGroups) == 0 {
+		for i := 0; i < int(aerospikeCluster.Spec.NodeCount); i++ {
+			res[i] = nil
+		}
+		return res
+	}
+
+	// keep the existing pods (which are sorted by index) in their node group
+	used := make(map[int]bool, len(pods))
+	counts := make(map[string]int, len(aerospikeCluster.Spec.NodeGroups))
+	var unlabeled []*corev1.Pod
+	for _, pod := range pods {
+		used[podIndex(pod)] = true
+		if _, ok := pod.Labels[selectors.LabelNodeGroupKey]; !ok {
+			unlabeled = append(unlabeled, pod)
+			continue
+		}
+		nodeGroup := getPodNodeGroup(aerospikeCluster, pod)
+		if nodeGroup == nil || counts[nodeGroup.Name] >= int(nodeGroup.NodeCount) {
+			continue
+		}
+		res[podIndex(pod)] = nodeGroup
+		counts[nodeGroup.Name]++
+	}
+	// pods created before node groups were defined are adopted by the node
+	// groups that have room for them, and are then restarted in place
+	for i := range aerospikeCluster.Spec.NodeGroups {
+		nodeGroup := &aerospikeCluster.Spec.NodeGroups[i]
+		for ; len(unlabeled) > 0 && counts[nodeGroup.Name] < int(nodeGroup.NodeCount); counts[nodeGroup.Name]++ {
+			res[podIndex(unlabeled[0])] = nodeGroup
+			unlabeled = unlabeled[1:]
+		}
+	}
+	// allocate free indexes to the node groups that need more pods
+	next := 0
+	for i := range aerospikeCluster.Spec.NodeGroups {
+		nodeGroup := &aerospikeCluster.Spec.NodeGroups[i]
+		for ; counts[nodeGroup.Name] < int(nodeGroup.NodeCount); counts[nodeGroup.Name]++ {
+			for used[next] {
+				next++
+			}
+			res[next] = nodeGroup
+			used[next] = true
+		}
+	}
+	return res
+}
+
+// getPodNodeGroup returns the node group to which the specified pod belongs, or
+// nil if it does not belong to any of the node groups in the cluster's spec.
+func getPodNodeGroup(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) *aerospikev1alpha2.NodeGroupSpec {
+	if pod == nil {
+		return nil
+	}
+	name, ok := pod.Labels[selectors.LabelNodeGroupKey]
+	if !ok {
+		return nil
+	}
+	return aerospikeCluster.Spec.GetNodeGroup(name)
+}
+
+// sortedPodIndexes returns the indexes of the specified pods in ascending
+// order.
+func sortedPodIndexes(pods map[int]*aerospikev1alpha2.NodeGroupSpec) []int {
+	res := make([]int, 0, len(pods))
+	for index := range pods {
+		res = append(res, index)
+	}
+	sort.Ints(res)
+	return res
+}
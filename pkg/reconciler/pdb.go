@@ -0,0 +1,150 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/crd"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+const (
+	// the replication factor used by aerospike when none is specified
+	// https://www.aerospike.com/docs/reference/configuration#replication-factor
+	defaultReplicationFactor int32 = 2
+)
+
+func (r *AerospikeClusterReconciler) ensurePodDisruptionBudget(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	maxUnavailable := intstr.FromInt(int(computeMaxUnavailable(aerospikeCluster)))
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: aerospikeCluster.Name,
+			Labels: map[string]string{
+				selectors.LabelAppKey:     selectors.LabelAppVal,
+				selectors.LabelClusterKey: aerospikeCluster.Name,
+			},
+			Namespace: aerospikeCluster.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         aerospikev1alpha2.SchemeGroupVersion.String(),
+					Kind:               crd.AerospikeClusterKind,
+					Name:               aerospikeCluster.Name,
+					UID:                aerospikeCluster.UID,
+					Controller:         pointers.NewBool(true),
+					BlockOwnerDeletion: pointers.NewBool(true),
+				},
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					selectors.LabelAppKey:     selectors.LabelAppVal,
+					selectors.LabelClusterKey: aerospikeCluster.Name,
+				},
+			},
+		},
+	}
+
+	if _, err := r.kubeclientset.PolicyV1().PodDisruptionBudgets(aerospikeCluster.Namespace).Create(ctx, pdb, metav1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Debug("poddisruptionbudget already exists")
+		return r.maybeUpdatePodDisruptionBudget(ctx, aerospikeCluster, pdb)
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug("poddisruptionbudget created")
+	return nil
+}
+
+// maybeUpdatePodDisruptionBudget updates the existing pod disruption budget for
+// the cluster if it differs from the desired one (e.g. because the replication
+// factor of a namespace has been changed).
+func (r *AerospikeClusterReconciler) maybeUpdatePodDisruptionBudget(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desired *policyv1.PodDisruptionBudget) error {
+	current, err := r.kubeclientset.PolicyV1().PodDisruptionBudgets(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(current.Spec.MaxUnavailable, desired.Spec.MaxUnavailable) && reflect.DeepEqual(current.Spec.Selector, desired.Spec.Selector) {
+		return nil
+	}
+	pdb := current.DeepCopy()
+	pdb.Spec.MaxUnavailable = desired.Spec.MaxUnavailable
+	pdb.Spec.Selector = desired.Spec.Selector
+	if _, err := r.kubeclientset.PolicyV1().PodDisruptionBudgets(pdb.Namespace).Update(ctx, pdb, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debugf("poddisruptionbudget updated (maxUnavailable=%s)", desired.Spec.MaxUnavailable.String())
+	return nil
+}
+
+// computeMaxUnavailable returns the maximum number of pods in the specified
+// cluster that may be voluntarily disrupted at the same time without losing
+// data. this is one less than the lowest effective replication factor among
+// the cluster's namespaces (both current and desired, so that a change in
+// progress is taken into account). in order not to block node drains forever,
+// at least one pod is always allowed to be disrupted.
+func computeMaxUnavailable(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) int32 {
+	minReplicationFactor := aerospikeCluster.Spec.NodeCount
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		if rf := effectiveReplicationFactor(aerospikeCluster, namespace.ReplicationFactor); rf < minReplicationFactor {
+			minReplicationFactor = rf
+		}
+	}
+	for _, namespace := range aerospikeCluster.Status.Namespaces {
+		if rf := effectiveReplicationFactor(aerospikeCluster, namespace.ReplicationFactor); rf < minReplicationFactor {
+			minReplicationFactor = rf
+		}
+	}
+	if minReplicationFactor <= 1 {
+		return 1
+	}
+	return minReplicationFactor - 1
+}
+
+// effectiveReplicationFactor returns the replication factor actually used by
+// aerospike for a namespace with the specified replication factor, which is
+// capped at the number of nodes in the cluster.
+func effectiveReplicationFactor(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, replicationFactor *int32) int32 {
+	res := defaultReplicationFactor
+	if replicationFactor != nil && *replicationFactor > 0 {
+		res = *replicationFactor
+	}
+	if res > aerospikeCluster.Spec.NodeCount {
+		res = aerospikeCluster.Spec.NodeCount
+	}
+	return res
+}
@@ -31,17 +31,17 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
-	"github.com/travelaudience/aerospike-operator/pkg/asutils"
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
@@ -57,15 +57,17 @@ const (
 	nodeIdPrefix = "a"
 )
 
-func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, upgrade *versioning.VersionUpgrade) error {
+func (r *AerospikeClusterReconciler) ensurePods(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, upgrade *versioning.VersionUpgrade) error {
 	// list existing pods for the cluster
 	pods, err := r.listClusterPods(aerospikeCluster)
 	if err != nil {
 		return err
 	}
-	// grab the current and desired size of the cluster
+	// grab the pods that must exist, as well as the current and desired size
+	// of the cluster
+	desiredPods := computeDesiredPods(aerospikeCluster, pods)
 	currentSize := len(pods)
-	desiredSize := int(aerospikeCluster.Spec.NodeCount)
+	desiredSize := len(desiredPods)
 
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
@@ -73,76 +75,159 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 		logfields.DesiredSize:      desiredSize,
 	}).Debug("checking if pods need to be updated")
 
-	// scale down if necessary
-	for i := currentSize - 1; i >= desiredSize; i-- {
-		if err := r.safeDeletePodWithIndex(aerospikeCluster, i); err != nil {
-			log.WithFields(log.Fields{
-				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-			}).Errorf("failed to delete pod with index %d: %v", i, err)
+	// resume the operation in progress (if any) before looking for further
+	// changes, as a single pod is operated on at a time
+	if err := r.refreshPodOperation(ctx, aerospikeCluster); err != nil {
+		return err
+	}
+	if aerospikeCluster.Status.PodOperation != nil {
+		if err := r.resumePodOperation(ctx, aerospikeCluster, configMap, upgrade, desiredPods); err != nil {
+			return err
+		}
+		if pods, err = r.listClusterPods(aerospikeCluster); err != nil {
+			return err
+		}
+		desiredPods = computeDesiredPods(aerospikeCluster, pods)
+	}
+
+	// scale down if necessary. pods are only deleted after the pods that
+	// replace them (e.g. in a different node group) have been created.
+	if len(aerospikeCluster.Spec.NodeGroups) == 0 {
+		if err := r.deleteExcessPods(ctx, aerospikeCluster, configMap, upgrade, pods, desiredPods); err != nil {
 			return err
 		}
 	}
 
 	// create/upgrade/restart existing pods as required
-	for i := 0; i < desiredSize; i++ {
+	for _, i := range sortedPodIndexes(desiredPods) {
+		nodeGroup := desiredPods[i]
 		// attempt to grab the pod with the specified index
 		pod, err := r.getPodWithIndex(aerospikeCluster, i)
 		if err != nil {
 			return err
 		}
 
-		// check whether the current pod is in a failure state, in which case we must delete and later re-create it
+		// check whether the current pod is in a failure state, in which case we must delete and re-create it
 		if pod != nil && isPodInFailureState(pod) {
 			log.WithFields(log.Fields{
 				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
 				logfields.Pod:              meta.Key(pod),
-			}).Warn("pod is in a failure state and will be deleted")
-			if err := r.deletePod(aerospikeCluster, pod); err != nil {
+			}).Warn("pod is in a failure state and will be re-created")
+			if err := r.runPodOperation(ctx, aerospikeCluster, configMap, upgrade, desiredPods, common.PodOperationTypeCreate, i, nodeGroup); err != nil {
 				return err
 			}
-			// set pod to nil since we have just deleted it
-			pod = nil
+			continue
 		}
 
-		switch {
-		// check whether the pod needs to be created
-		case pod == nil:
-			// no pod with the specified index exists, so it must be created
-			pod, err = r.createPodWithIndex(aerospikeCluster, configMap, i, nil)
-			if err != nil {
-				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-					logfields.PodIndex:         i,
-				}).Errorf("failed to create pod: %v", err)
+		// check whether the storage type or storage class of any namespace
+		// has changed, in which case the pod must be given new pvcs
+		needsStorageMigration := false
+		if pod != nil && upgrade == nil {
+			if needsStorageMigration, err = r.podNeedsStorageMigration(aerospikeCluster, pod); err != nil {
+				return err
+			}
+		}
+
+		// expand the persistent volume claims mounted by the pod if the
+		// requested storage size has been increased. the pod is restarted
+		// afterwards so that aerospike picks up the new size.
+		if pod != nil && !needsStorageMigration && !isPodReplacementRequested(pod) {
+			if err := r.ensurePersistentVolumeClaimsSize(ctx, aerospikeCluster, pod); err != nil {
 				return err
 			}
+		}
+
 		// check whether the pod needs to be upgraded
-		case upgrade != nil:
-			pod, err = r.maybeUpgradePodWithIndex(aerospikeCluster, configMap, i, upgrade)
+		needsUpgrade := false
+		if pod != nil && upgrade != nil {
+			version, err := getAerospikeServerVersionFromPod(ctx, pod)
 			if err != nil {
-				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-					logfields.PodIndex:         i,
-				}).Errorf("failed to upgrade pod: %v", err)
 				return err
 			}
-		// check whether the pod needs to be restarted
+			needsUpgrade = version != aerospikeCluster.Spec.Version
+		}
+
+		// pods created by previous versions of aerospike-operator do not
+		// hold the hash of their spec, which is stamped onto them instead of
+		// restarting them
+		if pod != nil {
+			if pod, err = r.ensurePodSpecHash(ctx, aerospikeCluster, pod); err != nil {
+				return err
+			}
+		}
+
+		var opType common.PodOperationType
+		switch {
+		// check whether the pod needs to be created
+		case pod == nil:
+			opType = common.PodOperationTypeCreate
+		// check whether the pod is being upgraded, in which case it is
+		// only restarted if it is not running the target version yet
+		case upgrade != nil:
+			if needsUpgrade {
+				opType = common.PodOperationTypeUpgrade
+			}
+		// check whether the pod has been marked for replacement
+		case isPodReplacementRequested(pod):
+			opType = common.PodOperationTypeReplace
+		// check whether the pod's storage needs to be migrated
+		case needsStorageMigration:
+			opType = common.PodOperationTypeMigrateStorage
+		// check whether the pod must be moved to a rack in a different
+		// failure domain, in which case it is given new pvcs as well
+		case podNeedsRackChange(aerospikeCluster, pod):
+			opType = common.PodOperationTypeReplace
+		// check whether the pod needs to be restarted, either because of a
+		// change to its configuration or spec or because a rolling restart
+		// has been requested
+		case podNeedsRestart(configMap, pod) || isPodSpecOutdated(aerospikeCluster, pod) || isPodRestartRequested(aerospikeCluster, pod):
+			opType = common.PodOperationTypeRestart
+		// check whether only dynamic configuration properties have changed
 		case configMap.Annotations[configMapHashAnnotation] != pod.Annotations[configMapHashAnnotation]:
-			pod, err = r.safeRestartPodWithIndex(aerospikeCluster, configMap, i, upgrade)
-			if err != nil {
+			if _, err = r.applyDynamicConfigToPod(ctx, aerospikeCluster, configMap, pod); err != nil {
+				// fallback to restarting the pod so that the changes are
+				// eventually applied
+				r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNodeConfigUpdateFailed,
+					"failed to apply dynamic configuration to pod with index %d, restarting it: %v", i, err)
 				log.WithFields(log.Fields{
 					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
 					logfields.PodIndex:         i,
-				}).Errorf("failed to restart pod: %v", err)
-				return err
+				}).Warnf("failed to apply dynamic configuration to pod, restarting it: %v", err)
+				opType = common.PodOperationTypeRestart
 			}
 		default:
-			// ensure aerospike is reachable and reports the correct clusterSize
-			if err := r.ensureClusterSize(aerospikeCluster, pod); err != nil {
+			// ensure aerospike is reachable and reports the correct
+			// clusterSize, restarting the pod if it fails to join the
+			// cluster in a timely manner
+			correct, err := r.isClusterSizeCorrect(ctx, aerospikeCluster, pod)
+			if err != nil {
 				return err
 			}
+			if !correct {
+				if time.Since(podReadySince(pod)) < waitClusterSizeTimeout {
+					return errors.NewRequeueError(time.Second, "waiting for pod %s to report the correct cluster size", meta.Key(pod))
+				}
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.Pod:              meta.Key(pod),
+				}).Warn("detected incorrect cluster size, restarting pod")
+				opType = common.PodOperationTypeRestart
+			}
+		}
+
+		if opType == "" {
+			continue
+		}
+		if err := r.runPodOperation(ctx, aerospikeCluster, configMap, upgrade, desiredPods, opType, i, nodeGroup); err != nil {
+			return err
 		}
+	}
 
+	// delete the pods that have been replaced by pods in a different node group
+	if len(aerospikeCluster.Spec.NodeGroups) > 0 {
+		if err := r.deleteExcessPods(ctx, aerospikeCluster, configMap, upgrade, pods, desiredPods); err != nil {
+			return err
+		}
 	}
 
 	// signal that we're good and return
@@ -153,6 +238,21 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 	return nil
 }
 
+// deleteExcessPods safely deletes the specified pods which are not part of
+// desiredPods, starting with the one with the highest index.
+func (r *AerospikeClusterReconciler) deleteExcessPods(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, upgrade *versioning.VersionUpgrade, pods []*corev1.Pod, desiredPods map[int]*aerospikev1alpha2.NodeGroupSpec) error {
+	for j := len(pods) - 1; j >= 0; j-- {
+		i := podIndex(pods[j])
+		if _, ok := desiredPods[i]; ok {
+			continue
+		}
+		if err := r.runPodOperation(ctx, aerospikeCluster, configMap, upgrade, desiredPods, common.PodOperationTypeDelete, i, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *AerospikeClusterReconciler) listClusterPods(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) ([]*corev1.Pod, error) {
 	// read the list of pods from the lister
 	pods, err := r.podsLister.Pods(aerospikeCluster.Namespace).List(selectors.ResourcesByClusterName(aerospikeCluster.Name))
@@ -184,7 +284,7 @@ func (r *AerospikeClusterReconciler) listClusterRunningPods(aerospikeCluster *ae
 	return runningPods, nil
 }
 
-func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, index int, upgrade *versioning.VersionUpgrade) (*corev1.Pod, error) {
+func (r *AerospikeClusterReconciler) createPodWithIndex(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, index int, nodeGroup *aerospikev1alpha2.NodeGroupSpec, upgrade *versioning.VersionUpgrade) (*corev1.Pod, error) {
 	// initialConfigFilePath contains the path to the aerospike.conf file that
 	// will be created as a result of mounting the configmap (i.e. before
 	// templating)
@@ -217,6 +317,12 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 	}
 	// build the comma-separated list of peers which to pass to asinit
 	peerList := strings.Join(peers, ",")
+	// grab the rack to which the pod belongs (if any)
+	rack := aerospikeCluster.Spec.GetRackForPodIndex(index)
+	rackId := ""
+	if rack != nil {
+		rackId = strconv.Itoa(int(rack.ID))
+	}
 
 	// pod represents the pod that will be created
 	pod := &corev1.Pod{
@@ -238,25 +344,31 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 				},
 			},
 			Annotations: map[string]string{
-				configMapHashAnnotation: configMap.Annotations[configMapHashAnnotation],
-				nodeIdAnnotation:        nodeId,
+				configMapHashAnnotation:     configMap.Annotations[configMapHashAnnotation],
+				staticConfigHashAnnotation:  configMap.Annotations[staticConfigHashAnnotation],
+				nodeIdAnnotation:            nodeId,
+				podSpecHashAnnotation:       computePodSpecHash(aerospikeCluster, index, nodeGroup),
+				restartGenerationAnnotation: strconv.FormatInt(aerospikeCluster.Spec.RestartGeneration, 10),
 			},
 		},
 		Spec: corev1.PodSpec{
 			// use a init container to set the values of service.node-id to the
-			// value of nodeId and of network.heartbeat.mesh-seed-adress-port[]
-			// to the list of currently active nodes
+			// value of nodeId, of network.heartbeat.mesh-seed-adress-port[]
+			// to the list of currently active nodes and of namespace.rack-id
+			// to the value of rackId
 			InitContainers: []corev1.Container{
 				{
-					Name:            "init",
-					Image:           fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-					ImagePullPolicy: corev1.PullAlways,
+					Name:            initContainerName,
+					Image:           images.Tools(aerospikeCluster.Spec.Images),
+					ImagePullPolicy: images.PullPolicy(aerospikeCluster.Spec.Images, corev1.PullAlways),
 					Command: []string{
 						"/usr/local/bin/asinit",
 						"--node-id",
 						nodeId,
 						"--peer-list",
 						peerList,
+						"--rack-id",
+						rackId,
 						"--source-config",
 						initialConfigFilePath,
 						"--target-config",
@@ -282,8 +394,9 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 			},
 			Containers: []corev1.Container{
 				{
-					Name:  "aerospike-server",
-					Image: fmt.Sprintf("aerospike/aerospike-server:%s", aerospikeCluster.Spec.Version),
+					Name:            aerospikeServerContainerName,
+					Image:           images.AerospikeServer(aerospikeCluster.Spec.Images, aerospikeCluster.Spec.Version),
+					ImagePullPolicy: images.PullPolicy(aerospikeCluster.Spec.Images, ""),
 					Command: []string{
 						"/usr/bin/asd",
 						"--foreground",
@@ -327,46 +440,7 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 						PeriodSeconds:       asReadinessPeriodSeconds,
 						FailureThreshold:    asReadinessFailureThreshold,
 					},
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU:    computeCpuRequest(aerospikeCluster),
-							corev1.ResourceMemory: computeMemoryRequest(aerospikeCluster),
-						},
-						Limits: computeResourceLimits(aerospikeCluster),
-					},
-				},
-				{
-					Name:  "asprom",
-					Image: fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-					Command: []string{
-						"asprom",
-					},
-					Ports: []corev1.ContainerPort{
-						{
-							Name:          "http",
-							ContainerPort: aspromPort,
-						},
-					},
-					LivenessProbe: &corev1.Probe{
-						ProbeHandler: corev1.ProbeHandler{
-							HTTPGet: &corev1.HTTPGetAction{
-								Path: "/metrics",
-								Port: intstr.IntOrString{
-									IntVal: aspromPort,
-								},
-							},
-						},
-					},
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse(aspromCpuRequest),
-							corev1.ResourceMemory: resource.MustParse(aspromMemoryRequest),
-						},
-						Limits: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse(aspromCpuLimit),
-							corev1.ResourceMemory: resource.MustParse(aspromMemoryLimit),
-						},
-					},
+					Resources: computeAerospikeServerResources(aerospikeCluster, nodeGroup),
 				},
 			},
 			Volumes: []corev1.Volume{
@@ -387,41 +461,68 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 					},
 				},
 			},
+			ImagePullSecrets: images.PullSecrets(aerospikeCluster.Spec.Images),
 			// let the reconcile loop handle pod restarts
 			RestartPolicy: corev1.RestartPolicyNever,
 			// use the pod's (stable) name as the hostname
 			Hostname: podName,
 			// use the cluster's name as the subdomain
 			Subdomain:    aerospikeCluster.Name,
-			NodeSelector: aerospikeCluster.Spec.NodeSelector,
-			Tolerations:  aerospikeCluster.Spec.Tolerations,
+			NodeSelector: computeNodeSelector(aerospikeCluster, nodeGroup, rack),
+			Tolerations:  computeTolerations(aerospikeCluster, nodeGroup),
 		},
 	}
 
+	// run the metrics exporter alongside aerospike
+	if isMonitoringEnabled(aerospikeCluster) {
+		pod.Spec.Containers = append(pod.Spec.Containers, computeExporterContainer(aerospikeCluster))
+	}
+	// record the rack to which the pod belongs
+	if rack != nil {
+		pod.Annotations[rackIdAnnotation] = rackId
+	}
+	// record the node group to which the pod belongs
+	if nodeGroup != nil {
+		pod.Labels[selectors.LabelNodeGroupKey] = nodeGroup.Name
+	}
+
 	// only enable in production, so it can be used in 1 node clusters while debugging (minikube)
 	if !debug.DebugEnabled {
-		pod.Spec.Affinity = &corev1.Affinity{
-			PodAntiAffinity: &corev1.PodAntiAffinity{
-				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+		term := corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
 					{
-						LabelSelector: &metav1.LabelSelector{
-							MatchExpressions: []metav1.LabelSelectorRequirement{
-								{
-									Key:      selectors.LabelAppKey,
-									Operator: metav1.LabelSelectorOpIn,
-									Values:   []string{selectors.LabelAppVal},
-								},
-								{
-									Key:      selectors.LabelClusterKey,
-									Operator: metav1.LabelSelectorOpIn,
-									Values:   []string{aerospikeCluster.Name},
-								},
-							},
-						},
-						TopologyKey: "kubernetes.io/hostname",
+						Key:      selectors.LabelAppKey,
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{selectors.LabelAppVal},
+					},
+					{
+						Key:      selectors.LabelClusterKey,
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{aerospikeCluster.Name},
 					},
 				},
 			},
+			TopologyKey: "kubernetes.io/hostname",
+		}
+		if aerospikeCluster.Spec.PodTemplate != nil && aerospikeCluster.Spec.PodTemplate.AntiAffinity == common.PodAntiAffinitySoft {
+			// allow for pods to be co-located when no other node is available
+			pod.Spec.Affinity = &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+						{
+							Weight:          100,
+							PodAffinityTerm: term,
+						},
+					},
+				},
+			}
+		} else {
+			pod.Spec.Affinity = &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+				},
+			}
 		}
 	}
 
@@ -440,20 +541,24 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 		// else get an existing one, and if it does not exist, create one
 		var pvc *corev1.PersistentVolumeClaim
 		if upgradeStrategy != nil && upgradeStrategy.RecreatePersistentVolumeClaims {
-			if pvc, err = r.createPersistentVolumeClaim(aerospikeCluster, pod, &namespace); err != nil {
+			if pvc, err = r.createPersistentVolumeClaim(ctx, aerospikeCluster, pod, &namespace); err != nil {
 				return nil, err
 			}
 		} else {
-			if pvc, err = r.getPersistentVolumeClaim(aerospikeCluster, pod); err != nil {
+			if pvc, err = r.getPersistentVolumeClaim(aerospikeCluster, pod, &namespace); err != nil {
 				return nil, err
 			}
 			if pvc != nil {
+				// make sure that the existing PVC is large enough
+				if err = r.expandPersistentVolumeClaim(ctx, aerospikeCluster, pvc, &namespace); err != nil {
+					return nil, err
+				}
 				// mark the PVC as mounted
-				if err = r.signalMounted(pvc); err != nil {
+				if err = r.signalMounted(ctx, pvc); err != nil {
 					return nil, err
 				}
 			} else {
-				if pvc, err = r.createPersistentVolumeClaim(aerospikeCluster, pod, &namespace); err != nil {
+				if pvc, err = r.createPersistentVolumeClaim(ctx, aerospikeCluster, pod, &namespace); err != nil {
 					return nil, err
 				}
 			}
@@ -487,116 +592,50 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 		})
 	}
 
-	// create the pod
-	res, err := r.kubeclientset.CoreV1().Pods(aerospikeCluster.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
-	if err != nil {
-		return nil, err
-	}
-
-	done := make(chan bool, 1)
-	go func() {
-		ticker := time.NewTicker(podOperationFeedbackPeriod)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeStarting,
-					"waiting for aerospike to start on pod %s", meta.Key(res))
-				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: res.Labels[selectors.LabelClusterKey],
-					logfields.Pod:              meta.Key(res),
-				}).Infof("waiting for aerospike to start on pod %s", meta.Key(res))
-			case success := <-done:
-				if success {
-					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeStarted,
-						"aerospike started on pod %s", meta.Key(res))
-					log.WithFields(log.Fields{
-						logfields.AerospikeCluster: res.Labels[selectors.LabelClusterKey],
-						logfields.Pod:              meta.Key(res),
-					}).Infof("aerospike started on pod %s", meta.Key(res))
-				} else {
-					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNodeStartedFailed,
-						"could not start aerospike on pod %s", meta.Key(res))
-					log.WithFields(log.Fields{
-						logfields.AerospikeCluster: res.Labels[selectors.LabelClusterKey],
-						logfields.Pod:              meta.Key(res),
-					}).Infof("could not start aerospike on pod %s", meta.Key(res))
-				}
-				return
-			}
-		}
-	}()
-
-	currentPod := res
-	err = r.waitForPodCondition(res, func(event watch.Event) (bool, error) {
-		switch event.Type {
-		case watch.Error:
-			return false, fmt.Errorf("got event of type error: %+v", event.Object)
-		case watch.Deleted:
-			currentPod = event.Object.(*corev1.Pod)
-			return false, fmt.Errorf("pod %s has been deleted", meta.Key(currentPod))
-		default:
-			currentPod = event.Object.(*corev1.Pod)
-			if isPodInFailureState(currentPod) {
-				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-					logfields.Pod:              meta.Key(currentPod),
-				}).Warn("pod is in a failure state")
-				if err := r.deletePod(aerospikeCluster, currentPod); err != nil {
-					return false, err
-				}
-				return false, fmt.Errorf("pod %s in a failure state has been deleted", meta.Key(currentPod))
+	// merge the user-provided settings into the pod
+	applyPodTemplate(aerospikeCluster, pod)
 
-			}
-			return isPodRunningAndReady(currentPod), nil
-		}
-	}, watchCreatePodTimeout)
-	done <- err == nil
-	close(done)
+	// create the pod
+	res, err := r.kubeclientset.CoreV1().Pods(aerospikeCluster.Namespace).Create(ctx, pod, metav1.CreateOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-		logfields.Pod:              meta.Key(currentPod),
-	}).Debug("pod created and running")
+		logfields.Pod:              meta.Key(res),
+	}).Debug("pod created")
 
-	return currentPod, nil
+	return res, nil
 }
 
-func (r *AerospikeClusterReconciler) deletePod(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
+// deletePod marks the pvcs mounted by the specified pod as unmounted and
+// requests the deletion of the pod, without waiting for it to be gone.
+func (r *AerospikeClusterReconciler) deletePod(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
 	// mark the pod PVCs as unmounted with an annotation
 	for _, volume := range pod.Spec.Volumes {
 		if claim := volume.PersistentVolumeClaim; claim != nil {
-			pvc, err := r.kubeclientset.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(context.TODO(), claim.ClaimName, metav1.GetOptions{})
+			pvc, err := r.kubeclientset.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, claim.ClaimName, metav1.GetOptions{})
 			if err != nil {
 				return err
 			}
-			if err := r.signalUnmounted(pvc); err != nil {
+			if err := r.signalUnmounted(ctx, pvc); err != nil {
 				return err
 			}
 		}
 	}
 	// delete the pod
-	err := r.kubeclientset.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{
+	err := r.kubeclientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{
 		GracePeriodSeconds: pointers.NewInt64FromFloat64(terminationGracePeriod.Seconds()),
 	})
-	if err != nil {
-		return err
-	}
-	// wait for the pod to be successfully deleted
-	err = r.waitForPodCondition(pod, func(event watch.Event) (bool, error) {
-		return event.Type == watch.Deleted, nil
-	}, watchDeletePodTimeout)
-	if err != nil {
+	if err != nil && !kubeerrors.IsNotFound(err) {
 		return err
 	}
 
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
 		logfields.Pod:              meta.Key(pod),
-	}).Debug("pod has been deleted")
+	}).Debug("pod deletion requested")
 	return nil
 }
 
@@ -604,7 +643,7 @@ func (r *AerospikeClusterReconciler) getPodWithIndex(aerospikeCluster *aerospike
 	// look for the pod with the specified index
 	p, err := r.podsLister.Pods(aerospikeCluster.Namespace).Get(fmt.Sprintf("%s-%d", aerospikeCluster.Name, index))
 	if err != nil {
-		if !errors.IsNotFound(err) {
+		if !kubeerrors.IsNotFound(err) {
 			// we've failed to get the pod with the specified index
 			log.WithFields(log.Fields{
 				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
@@ -620,98 +659,34 @@ func (r *AerospikeClusterReconciler) getPodWithIndex(aerospikeCluster *aerospike
 	return p, nil
 }
 
-func (r *AerospikeClusterReconciler) safeDeletePodWithIndex(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int) error {
-	// check whether a pod with the specified index exists
-	pod, err := r.getPodWithIndex(aerospikeCluster, index)
-	if err != nil {
-		// we've failed to get the pod with the specified index
-		return err
-	}
-	if pod == nil {
-		// no pod with the specified index exists
-		return nil
-	}
-	// check whether the pod is participating in migrations
-	migrations, err := podHasMigrationsInProgress(pod)
-	if err != nil {
-		return err
-	}
-	// if the pod is participating in migrations, we wait for them to finish and
-	// keep on giving feedback
-	if migrations {
-		done := make(chan bool, 1)
-		go func() {
-			ticker := time.NewTicker(podOperationFeedbackPeriod)
-			defer ticker.Stop()
-			log.WithFields(log.Fields{
-				logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-				logfields.Pod:              meta.Key(pod),
-			}).Info("waiting for migrations to finish")
-			r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonWaitForMigrationsStarted,
-				"waiting for migrations to finish on pod %s",
-				meta.Key(pod),
-			)
-			for {
-				select {
-				case <-ticker.C:
-					log.WithFields(log.Fields{
-						logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-						logfields.Pod:              meta.Key(pod),
-					}).Info("waiting for migrations to finish")
-					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonWaitingForMigrations,
-						"waiting for migrations to finish on pod %s",
-						meta.Key(pod),
-					)
-				case <-done:
-					log.WithFields(log.Fields{
-						logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-						logfields.Pod:              meta.Key(pod),
-					}).Info("migrations finished")
-					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonWaitForMigrationsFinished,
-						"migrations finished on pod %s",
-						meta.Key(pod),
-					)
-					return
-				}
-			}
-		}()
-		if err := waitForMigrationsToFinishOnPod(pod); err != nil {
-			log.WithFields(log.Fields{
-				logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-				logfields.Pod:              meta.Key(pod),
-			}).Error("failed to wait for migrations to finish")
-			return err
-		}
-		close(done)
-	}
-	// delete the pod now that migrations are finished
-	if err := r.deletePod(aerospikeCluster, pod); err != nil {
-		return err
-	}
-
+// forgetDeletedPod makes the remaining aerospike nodes forget about the node
+// that ran on the (deleted) pod with the specified index, by tip-clearing its
+// hostname and resetting their list of alumni.
+func (r *AerospikeClusterReconciler) forgetDeletedPod(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int) error {
 	// get a list of the pods
 	pods, err := r.listClusterPods(aerospikeCluster)
 	if err != nil {
 		return err
 	}
 
-	// tip-clear the name of the current pod
+	// tip-clear the name of the deleted pod
 	// and alumni-reset on all pods
+	podName := fmt.Sprintf("%s-%d", aerospikeCluster.Name, index)
 	var wg sync.WaitGroup
 	wg.Add(len(pods))
 	for _, p := range pods {
 		go func(p *corev1.Pod) {
 			defer wg.Done()
-			if err := tipClearHostname(p, fmt.Sprintf("%s.%s.%s", pod.Name, aerospikeCluster.Name, aerospikeCluster.Namespace)); err != nil {
+			if err := tipClearHostname(ctx, p, fmt.Sprintf("%s.%s.%s", podName, aerospikeCluster.Name, aerospikeCluster.Namespace)); err != nil {
 				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-					logfields.Pod:              meta.Key(pod),
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.PodIndex:         index,
 				}).Errorf("failed tip-clear ip on pod %q", meta.Key(p))
 			}
-			if err := alumniReset(p); err != nil {
+			if err := alumniReset(ctx, p); err != nil {
 				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-					logfields.Pod:              meta.Key(pod),
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.PodIndex:         index,
 				}).Errorf("failed alumni-reset on pod %q", meta.Key(p))
 			}
 		}(p)
@@ -720,15 +695,135 @@ func (r *AerospikeClusterReconciler) safeDeletePodWithIndex(aerospikeCluster *ae
 	return nil
 }
 
-func (r *AerospikeClusterReconciler) safeRestartPodWithIndex(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, index int, upgrade *versioning.VersionUpgrade) (*corev1.Pod, error) {
+// quiescePod quiesces the aerospike node running on the specified pod so that
+// it hands off its master partitions to the remaining nodes before the pod is
+// deleted. it returns whether the node owns no master partitions anymore, or
+// true if the node cannot be quiesced.
+func (r *AerospikeClusterReconciler) quiescePod(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) (bool, error) {
+	supported, err := supportsQuiesce(ctx, pod)
+	if err != nil {
+		return false, err
+	}
+	if !supported {
+		return true, nil
+	}
+	// a node that is alone in the cluster has no one to hand off its
+	// partitions to
+	size, err := getClusterSize(ctx, pod)
+	if err != nil {
+		return false, err
+	}
+	if size < 2 {
+		return true, nil
+	}
+	stats, err := getNamespaceStatistics(ctx, pod)
+	if err != nil {
+		return false, err
+	}
+	pending, effective := true, true
+	var masterObjects int64
+	for _, s := range stats {
+		pending = pending && s["pending_quiesce"] == "true"
+		effective = effective && s["effective_is_quiesced"] == "true"
+		if v, err := strconv.ParseInt(s["master_objects"], 10, 64); err == nil {
+			masterObjects += v
+		}
+	}
+	if !pending {
+		if _, err := runInfoCommandOnPod(ctx, pod, "quiesce:"); err != nil {
+			return false, err
+		}
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			logfields.Pod:              meta.Key(pod),
+		}).Debug("aerospike node quiesced")
+	}
+	// quiescing only takes effect once the cluster is reclustered
+	if !effective {
+		return false, r.recluster(ctx, aerospikeCluster)
+	}
+	return masterObjects == 0, nil
+}
+
+// undoQuiescePod puts the aerospike node running on the specified pod back into
+// service when the pod is not going to be deleted after all.
+func (r *AerospikeClusterReconciler) undoQuiescePod(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
+	supported, err := supportsQuiesce(ctx, pod)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return nil
+	}
+	if _, err := runInfoCommandOnPod(ctx, pod, "quiesce-undo:"); err != nil {
+		return err
+	}
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-	}).Debugf("restarting the pod with index %d", index)
+		logfields.Pod:              meta.Key(pod),
+	}).Debug("aerospike node no longer quiesced")
+	// undoing the quiescing only takes effect once the cluster is reclustered
+	return r.recluster(ctx, aerospikeCluster)
+}
 
-	if err := r.safeDeletePodWithIndex(aerospikeCluster, index); err != nil {
-		return nil, err
+// recluster asks the aerospike cluster to recluster. since the request is only
+// honored by the principal node, it is sent to every running pod.
+func (r *AerospikeClusterReconciler) recluster(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	pods, err := r.listClusterRunningPods(aerospikeCluster)
+	if err != nil {
+		return err
 	}
-	return r.createPodWithIndex(aerospikeCluster, configMap, index, upgrade)
+	for _, p := range pods {
+		if !isPodRunningAndReady(p) {
+			continue
+		}
+		if _, err := runInfoCommandOnPod(ctx, p, "recluster:"); err != nil {
+			return fmt.Errorf("failed to recluster on pod %s: %v", meta.Key(p), err)
+		}
+	}
+	return nil
+}
+
+// isClusterStable returns whether every aerospike node agrees on the same
+// cluster key and cluster size and has no migrations in progress. pods in a
+// failure state are left out, as they are re-created anyway, and every other
+// pod must be running and ready. the specified target pod (if any) is left out
+// as well if it is not running and ready, as it is about to be deleted and may
+// never become ready.
+func (r *AerospikeClusterReconciler) isClusterStable(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, target *corev1.Pod) (bool, error) {
+	pods, err := r.listClusterRunningPods(aerospikeCluster)
+	if err != nil {
+		return false, err
+	}
+	// the remaining nodes may or may not count a target pod which is not
+	// ready as a member of the cluster
+	clusterSizes := []int{len(pods)}
+	nodes := make([]*corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		ready := pod.DeletionTimestamp == nil && isPodRunningAndReady(pod)
+		if target != nil && pod.Name == target.Name && !ready {
+			clusterSizes = append(clusterSizes, len(pods)-1)
+			continue
+		}
+		if !ready {
+			return false, nil
+		}
+		nodes = append(nodes, pod)
+	}
+	clusterKey := ""
+	for _, pod := range nodes {
+		key, err := getStableClusterKey(ctx, pod, clusterSizes)
+		if err != nil {
+			return false, err
+		}
+		if clusterKey == "" {
+			clusterKey = key
+		}
+		if clusterKey == "" || key != clusterKey {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 func (r *AerospikeClusterReconciler) computeMeshHash(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (string, error) {
@@ -745,46 +840,84 @@ func (r *AerospikeClusterReconciler) computeMeshHash(aerospikeCluster *aerospike
 	return asstrings.HashSlice(addrList), nil
 }
 
-func (r *AerospikeClusterReconciler) ensureClusterSize(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
-	timer := time.NewTimer(waitClusterSizeTimeout)
-	defer timer.Stop()
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ticker.C:
-			// get the current list of pods
-			pods, err := r.listClusterRunningPods(aerospikeCluster)
-			if err != nil {
-				return err
-			}
-			// get the cluster size reported by the current node
-			clusterSize, err := asutils.GetClusterSize(pod.Status.PodIP, ServicePort)
-			if err != nil {
-				return err
-			}
-			// if the cluster size is the expected, return
-			// asprom can be down but cluster still healthy
-			if clusterSize >= len(pods) {
-				return nil
-			}
-		case <-timer.C:
-			// the clusterSize is different than the expected, hence we delete
-			// the pod so it can be re-created in the next reconcile loop
-			if err := r.safeDeletePodWithIndex(aerospikeCluster, podIndex(pod)); err != nil {
-				return err
-			}
-			return fmt.Errorf("detected incorrect cluster size for pod %q", meta.Key(pod))
-		}
+// isClusterSizeCorrect returns whether the aerospike node running in the
+// specified pod reports a cluster size that matches the number of running pods.
+func (r *AerospikeClusterReconciler) isClusterSizeCorrect(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) (bool, error) {
+	// get the current list of pods
+	pods, err := r.listClusterRunningPods(aerospikeCluster)
+	if err != nil {
+		return false, err
+	}
+	// get the cluster size reported by the current node
+	clusterSize, err := getClusterSize(ctx, pod)
+	if err != nil {
+		return false, err
+	}
+	// asprom can be down but cluster still healthy
+	return clusterSize >= len(pods), nil
+}
+
+// computeNodeSelector returns the node selector for a pod belonging to the
+// specified node group and rack, which is the union of the node group's (or
+// the cluster's) node selector, the rack's node selector and its zone.
+func computeNodeSelector(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeGroup *aerospikev1alpha2.NodeGroupSpec, rack *aerospikev1alpha2.RackSpec) map[string]string {
+	nodeSelector := aerospikeCluster.Spec.NodeSelector
+	if nodeGroup != nil && nodeGroup.NodeSelector != nil {
+		nodeSelector = nodeGroup.NodeSelector
+	}
+	if rack == nil || (rack.Zone == "" && len(rack.NodeSelector) == 0) {
+		return nodeSelector
+	}
+	res := make(map[string]string)
+	for key, value := range nodeSelector {
+		res[key] = value
+	}
+	for key, value := range rack.NodeSelector {
+		res[key] = value
+	}
+	if rack.Zone != "" {
+		res[corev1.LabelTopologyZone] = rack.Zone
+	}
+	return res
+}
+
+// computeTolerations returns the tolerations for a pod belonging to the
+// specified node group.
+func computeTolerations(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeGroup *aerospikev1alpha2.NodeGroupSpec) []corev1.Toleration {
+	if nodeGroup != nil && nodeGroup.Tolerations != nil {
+		return nodeGroup.Tolerations
+	}
+	return aerospikeCluster.Spec.Tolerations
+}
+
+// computeResources returns the resource requirements requested by the user for
+// the aerospike-server container of a pod belonging to the specified node
+// group.
+func computeResources(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeGroup *aerospikev1alpha2.NodeGroupSpec) *corev1.ResourceRequirements {
+	if nodeGroup != nil && nodeGroup.Resources != nil {
+		return nodeGroup.Resources
+	}
+	return aerospikeCluster.Spec.Resources
+}
+
+// computeAerospikeServerResources returns the resource requirements of the
+// aerospike-server container of a pod belonging to the specified node group.
+func computeAerospikeServerResources(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeGroup *aerospikev1alpha2.NodeGroupSpec) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    computeCpuRequest(aerospikeCluster, nodeGroup),
+			corev1.ResourceMemory: computeMemoryRequest(aerospikeCluster, nodeGroup),
+		},
+		Limits: computeResourceLimits(aerospikeCluster, nodeGroup),
 	}
 }
 
 // computeCpuRequest computes the amount of cpu to be requested for the aerospike-server container and returns the
 // corresponding resource.Quantity. It currently returns aerospikeServerContainerDefaultCpuRequest parsed as a quantity
 // or requested CPU provided by user if it exists as a quantity.
-func computeCpuRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) resource.Quantity {
-	if aerospikeCluster.Spec.Resources != nil && aerospikeCluster.Spec.Resources.Requests.Cpu() != nil {
-		return *aerospikeCluster.Spec.Resources.Requests.Cpu()
+func computeCpuRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeGroup *aerospikev1alpha2.NodeGroupSpec) resource.Quantity {
+	if resources := computeResources(aerospikeCluster, nodeGroup); resources != nil && resources.Requests.Cpu() != nil {
+		return *resources.Requests.Cpu()
 	}
 	return resource.MustParse(strconv.Itoa(aerospikeServerContainerDefaultCpuRequest))
 }
@@ -792,7 +925,7 @@ func computeCpuRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) res
 // computeMemoryRequest computes the amount of memory to be requested for the aerospike-server container based on the
 // value of the memorySize field of each namespace. Compares computed amount of memory with user provided memory request and
 // returns the biggest amount as a resource.Quantity.
-func computeMemoryRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) resource.Quantity {
+func computeMemoryRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeGroup *aerospikev1alpha2.NodeGroupSpec) resource.Quantity {
 	sum := 0
 	for _, ns := range aerospikeCluster.Spec.Namespaces {
 		if ns.MemorySize == nil {
@@ -816,8 +949,8 @@ func computeMemoryRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster)
 	}
 	computedMemory := resource.MustParse(fmt.Sprintf("%dGi", sum))
 	// user may want to setup manual memory requests bigger than computed ones
-	if aerospikeCluster.Spec.Resources != nil && aerospikeCluster.Spec.Resources.Requests.Memory() != nil && aerospikeCluster.Spec.Resources.Requests.Memory().Cmp(computedMemory) > 0 {
-		computedMemory = *aerospikeCluster.Spec.Resources.Requests.Memory()
+	if resources := computeResources(aerospikeCluster, nodeGroup); resources != nil && resources.Requests.Memory() != nil && resources.Requests.Memory().Cmp(computedMemory) > 0 {
+		computedMemory = *resources.Requests.Memory()
 	}
 
 	return computedMemory
@@ -825,26 +958,27 @@ func computeMemoryRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster)
 
 // computeResourceLimits computes the limit amounts of cpu and memory to be used by the aerospike-server container
 // and returns the corresponding ResourceList.
-func computeResourceLimits(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) corev1.ResourceList {
+func computeResourceLimits(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeGroup *aerospikev1alpha2.NodeGroupSpec) corev1.ResourceList {
+	resources := computeResources(aerospikeCluster, nodeGroup)
 	// compute configured resource limits, if any
-	if aerospikeCluster.Spec.Resources != nil && aerospikeCluster.Spec.Resources.Limits != nil {
+	if resources != nil && resources.Limits != nil {
 		// setup limits for memory and cpu if user provides request limit values for both
-		if aerospikeCluster.Spec.Resources.Limits.Cpu() != nil && !aerospikeCluster.Spec.Resources.Limits.Cpu().IsZero() && aerospikeCluster.Spec.Resources.Limits.Memory() != nil && !aerospikeCluster.Spec.Resources.Limits.Memory().IsZero() {
+		if resources.Limits.Cpu() != nil && !resources.Limits.Cpu().IsZero() && resources.Limits.Memory() != nil && !resources.Limits.Memory().IsZero() {
 			return corev1.ResourceList{
-				corev1.ResourceCPU:    *aerospikeCluster.Spec.Resources.Limits.Cpu(),
-				corev1.ResourceMemory: *aerospikeCluster.Spec.Resources.Limits.Memory(),
+				corev1.ResourceCPU:    *resources.Limits.Cpu(),
+				corev1.ResourceMemory: *resources.Limits.Memory(),
 			}
 		} else {
 			// setup limits for cpu if user provides request limit values for cpu only
-			if aerospikeCluster.Spec.Resources.Limits.Cpu() != nil && !aerospikeCluster.Spec.Resources.Limits.Cpu().IsZero() {
+			if resources.Limits.Cpu() != nil && !resources.Limits.Cpu().IsZero() {
 				return corev1.ResourceList{
-					corev1.ResourceCPU: *aerospikeCluster.Spec.Resources.Limits.Cpu(),
+					corev1.ResourceCPU: *resources.Limits.Cpu(),
 				}
 			}
 			// setup limits for memory if user provides request limit values for memory only
-			if aerospikeCluster.Spec.Resources.Limits.Memory() != nil && !aerospikeCluster.Spec.Resources.Limits.Memory().IsZero() {
+			if resources.Limits.Memory() != nil && !resources.Limits.Memory().IsZero() {
 				return corev1.ResourceList{
-					corev1.ResourceMemory: *aerospikeCluster.Spec.Resources.Limits.Memory(),
+					corev1.ResourceMemory: *resources.Limits.Memory(),
 				}
 			}
 		}
@@ -0,0 +1,487 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+// runPodOperation starts an operation of the specified type on the pod with
+// the specified index and performs as many of its steps as possible. it
+// returns a RequeueError if the operation must be resumed later.
+func (r *AerospikeClusterReconciler) runPodOperation(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, upgrade *versioning.VersionUpgrade, desiredPods map[int]*aerospikev1alpha2.NodeGroupSpec, opType common.PodOperationType, index int, nodeGroup *aerospikev1alpha2.NodeGroupSpec) error {
+	if err := r.startPodOperation(ctx, aerospikeCluster, opType, index, nodeGroup); err != nil {
+		return err
+	}
+	return r.resumePodOperation(ctx, aerospikeCluster, configMap, upgrade, desiredPods)
+}
+
+// startPodOperation records the start of an operation of the specified type on
+// the pod with the specified index in the status of the cluster.
+func (r *AerospikeClusterReconciler) startPodOperation(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, opType common.PodOperationType, index int, nodeGroup *aerospikev1alpha2.NodeGroupSpec) error {
+	op := &aerospikev1alpha2.PodOperation{
+		Type:          opType,
+		PodIndex:      index,
+		Step:          common.PodOperationStepWaitingForMigrations,
+		StepStartTime: metav1.NewTime(time.Now()),
+	}
+	// pods being created have nothing to wait for before being created
+	if opType == common.PodOperationTypeCreate {
+		op.Step = common.PodOperationStepCreating
+	}
+	if nodeGroup != nil {
+		op.NodeGroup = nodeGroup.Name
+	}
+	if err := r.setPodOperation(ctx, aerospikeCluster, op); err != nil {
+		return err
+	}
+
+	switch opType {
+	case common.PodOperationTypeUpgrade:
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeUpgradeStarted,
+			"upgrading pod with index %d to version %s", index, aerospikeCluster.Spec.Version)
+	case common.PodOperationTypeMigrateStorage:
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonStorageMigrationStarted,
+			"migrating the storage of the pod with index %d", index)
+	case common.PodOperationTypeReplace:
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeReplacementStarted,
+			"replacing the pod with index %d", index)
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.PodIndex:         index,
+	}).Infof("%s operation started", opType)
+	return nil
+}
+
+// resumePodOperation performs as many steps as possible of the operation
+// recorded in the status of the cluster. it returns nil once the operation has
+// finished, a RequeueError if the operation must be resumed later, and
+// errors.PodUpgradeFailed if the pod did not come back with the target version
+// of aerospike (in which case the operation is abandoned).
+func (r *AerospikeClusterReconciler) resumePodOperation(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, upgrade *versioning.VersionUpgrade, desiredPods map[int]*aerospikev1alpha2.NodeGroupSpec) error {
+	for {
+		op := aerospikeCluster.Status.PodOperation
+		if op == nil {
+			return nil
+		}
+		// stop between steps if we have been asked to, as the current step is
+		// recorded in the status and may be resumed by the next leader
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pod, err := r.getPodWithIndex(aerospikeCluster, op.PodIndex)
+		if err != nil {
+			return err
+		}
+
+		switch op.Step {
+		case common.PodOperationStepWaitingForMigrations:
+			// wait for the cluster to be stable (i.e. for every node to
+			// agree on the cluster and for migrations to finish) before
+			// quiescing the pod. the pod is not deleted if the cluster does
+			// not stabilize in time.
+			if pod != nil && pod.DeletionTimestamp == nil {
+				// back off before quiescing a node which previously failed
+				// to hand off its master partitions in time. the cluster is
+				// only given time to stabilize once the backoff is over.
+				waitStartTime := op.StepStartTime.Time
+				if isPodRunningAndReady(pod) {
+					backoff := getQuiesceBackoffPeriod(op.QuiesceFailures)
+					if remaining := backoff - time.Since(waitStartTime); remaining > 0 {
+						return errors.NewRequeueError(remaining, "pod %s failed to hand off its master partitions %d time(s), backing off for %s", meta.Key(pod), op.QuiesceFailures, remaining)
+					}
+					waitStartTime = waitStartTime.Add(backoff)
+				}
+				stable, err := r.isClusterStable(ctx, aerospikeCluster, pod)
+				if err != nil {
+					return err
+				}
+				if !stable {
+					if timeout := getStabilizationTimeout(aerospikeCluster); time.Since(waitStartTime) > timeout {
+						return r.haltPodOperation(aerospikeCluster, timeout)
+					}
+					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonWaitingForMigrations,
+						"waiting for the cluster to be stable before deleting pod %s", meta.Key(pod))
+					return errors.NewRequeueError(migrationsPollPeriod, "waiting for the cluster to be stable before deleting pod %s", meta.Key(pod))
+				}
+				// a pod which is not ready cannot be quiesced, so it is
+				// deleted right away
+				if !isPodRunningAndReady(pod) {
+					if err := r.deletePod(ctx, aerospikeCluster, pod); err != nil {
+						return err
+					}
+					if err := r.setPodOperationStep(ctx, aerospikeCluster, common.PodOperationStepDeleting); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if err := r.setPodOperationStep(ctx, aerospikeCluster, common.PodOperationStepQuiescing); err != nil {
+				return err
+			}
+
+		case common.PodOperationStepQuiescing:
+			// wait for the node to hand off its master partitions to the
+			// remaining nodes before deleting the pod, so that clients do not
+			// see errors while partitions move. a pod which has stopped being
+			// ready in the meantime is deleted right away.
+			if pod != nil && pod.DeletionTimestamp == nil {
+				quiesced := !isPodRunningAndReady(pod)
+				if !quiesced {
+					if quiesced, err = r.quiescePod(ctx, aerospikeCluster, pod); err != nil {
+						return err
+					}
+				}
+				if !quiesced {
+					if timeout := getQuiesceTimeout(aerospikeCluster); time.Since(op.StepStartTime.Time) > timeout {
+						if err := r.cancelPodQuiescing(ctx, aerospikeCluster, pod); err != nil {
+							return err
+						}
+						return r.haltPodQuiescing(aerospikeCluster, pod, timeout)
+					}
+					return errors.NewRequeueError(migrationsPollPeriod, "waiting for pod %s to hand off its master partitions", meta.Key(pod))
+				}
+				if err := r.deletePod(ctx, aerospikeCluster, pod); err != nil {
+					return err
+				}
+			}
+			if err := r.setPodOperationStep(ctx, aerospikeCluster, common.PodOperationStepDeleting); err != nil {
+				return err
+			}
+
+		case common.PodOperationStepDeleting:
+			// wait for the pod to be gone
+			if pod != nil {
+				if time.Since(op.StepStartTime.Time) > deletePodTimeout {
+					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNodeDeletionFailed,
+						"pod %s was not deleted within %s", meta.Key(pod), deletePodTimeout)
+					return errors.NewUnstableClusterError("pod %s was not deleted within %s", meta.Key(pod), deletePodTimeout)
+				}
+				return errors.NewRequeueError(0, "waiting for pod %s to be deleted", meta.Key(pod))
+			}
+			// make the remaining nodes forget about the deleted one
+			if err := r.forgetDeletedPod(ctx, aerospikeCluster, op.PodIndex); err != nil {
+				return err
+			}
+			if op.Type == common.PodOperationTypeDelete {
+				return r.finishPodOperation(ctx, aerospikeCluster, desiredPods)
+			}
+			// make sure the pvcs used by the replaced pod are not reused
+			if op.Type == common.PodOperationTypeReplace {
+				if err := r.markPersistentVolumeClaimsReplaced(ctx, aerospikeCluster, op.PodIndex); err != nil {
+					return err
+				}
+			}
+			if err := r.setPodOperationStep(ctx, aerospikeCluster, common.PodOperationStepCreating); err != nil {
+				return err
+			}
+
+		case common.PodOperationStepCreating:
+			if pod == nil {
+				// the pod may not be needed anymore (e.g. if the cluster has
+				// been scaled down in the meantime)
+				if _, ok := desiredPods[op.PodIndex]; !ok {
+					return r.finishPodOperation(ctx, aerospikeCluster, desiredPods)
+				}
+				// the pod is created with the target version of aerospike
+				// only when it is being upgraded
+				var podUpgrade *versioning.VersionUpgrade
+				if op.Type == common.PodOperationTypeUpgrade {
+					podUpgrade = upgrade
+				}
+				pod, err := r.createPodWithIndex(ctx, aerospikeCluster, configMap, op.PodIndex, aerospikeCluster.Spec.GetNodeGroup(op.NodeGroup), podUpgrade)
+				if err != nil {
+					// the lister may not have caught up with the pod
+					// created in a previous attempt
+					if kubeerrors.IsAlreadyExists(err) {
+						return errors.NewRequeueError(0, "waiting for pod with index %d to be observed", op.PodIndex)
+					}
+					return err
+				}
+				r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeStarting,
+					"waiting for aerospike to start on pod %s", meta.Key(pod))
+				return errors.NewRequeueError(0, "waiting for aerospike to start on pod %s", meta.Key(pod))
+			}
+			// wait for a pod in a failure state to be gone before re-creating it
+			if pod.DeletionTimestamp != nil {
+				return errors.NewRequeueError(0, "waiting for pod %s to be deleted", meta.Key(pod))
+			}
+			if isPodInFailureState(pod) {
+				r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNodeStartedFailed,
+					"could not start aerospike on pod %s", meta.Key(pod))
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.Pod:              meta.Key(pod),
+				}).Warn("pod is in a failure state")
+				if err := r.deletePod(ctx, aerospikeCluster, pod); err != nil {
+					return err
+				}
+				return errors.NewRequeueError(0, "waiting for pod %s in a failure state to be deleted", meta.Key(pod))
+			}
+			if !isPodRunningAndReady(pod) {
+				// re-create a pod which does not become ready in time rather
+				// than waiting for it forever
+				if time.Since(op.StepStartTime.Time) > createPodTimeout {
+					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNodeStartedFailed,
+						"aerospike did not start on pod %s within %s", meta.Key(pod), createPodTimeout)
+					log.WithFields(log.Fields{
+						logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+						logfields.Pod:              meta.Key(pod),
+					}).Warnf("pod did not become ready within %s", createPodTimeout)
+					if err := r.deletePod(ctx, aerospikeCluster, pod); err != nil {
+						return err
+					}
+					// restart the step so that the new pod gets the whole
+					// timeout to become ready
+					if err := r.setPodOperationStep(ctx, aerospikeCluster, common.PodOperationStepCreating); err != nil {
+						return err
+					}
+					return errors.NewRequeueError(0, "waiting for pod %s to be deleted", meta.Key(pod))
+				}
+				return errors.NewRequeueError(0, "waiting for aerospike to start on pod %s", meta.Key(pod))
+			}
+			r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeStarted,
+				"aerospike started on pod %s", meta.Key(pod))
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+				logfields.Pod:              meta.Key(pod),
+			}).Debug("pod created and running")
+			// make sure the pod is running the target version
+			if op.Type == common.PodOperationTypeUpgrade {
+				version, err := getAerospikeServerVersionFromPod(ctx, pod)
+				if err != nil {
+					return err
+				}
+				if version != aerospikeCluster.Spec.Version {
+					r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNodeUpgradeFailed,
+						"failed to upgrade pod %s to version %s", meta.Key(pod), aerospikeCluster.Spec.Version)
+					log.WithFields(log.Fields{
+						logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+						logfields.Pod:              meta.Key(pod),
+					}).Warnf("pod is running version %s instead of %s", version, aerospikeCluster.Spec.Version)
+					// failed upgrades are not retried, so the operation is
+					// abandoned and the failure is signaled by the caller
+					if err := r.setPodOperation(ctx, aerospikeCluster, nil); err != nil {
+						return err
+					}
+					return errors.PodUpgradeFailed
+				}
+			}
+			// wait for the node to join the cluster and for data to be
+			// migrated to it when migrating its storage, replacing it or
+			// changing the replication factor
+			if op.Type != common.PodOperationTypeMigrateStorage && op.Type != common.PodOperationTypeReplace && !(op.Type == common.PodOperationTypeRestart && isReplicationFactorChangeInProgress(aerospikeCluster)) {
+				return r.finishPodOperation(ctx, aerospikeCluster, desiredPods)
+			}
+			if err := r.setPodOperationStep(ctx, aerospikeCluster, common.PodOperationStepSettling); err != nil {
+				return err
+			}
+
+		case common.PodOperationStepSettling:
+			// the pod may have been deleted in the meantime
+			if pod == nil {
+				if err := r.setPodOperationStep(ctx, aerospikeCluster, common.PodOperationStepCreating); err != nil {
+					return err
+				}
+				continue
+			}
+			stable, err := r.isClusterStable(ctx, aerospikeCluster, nil)
+			if err != nil {
+				return err
+			}
+			if !stable {
+				if timeout := getStabilizationTimeout(aerospikeCluster); time.Since(op.StepStartTime.Time) > timeout {
+					if op.Type == common.PodOperationTypeMigrateStorage {
+						r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonStorageMigrationFailed,
+							"timed out waiting for migrations to finish on pod %s", meta.Key(pod))
+					}
+					return r.haltPodOperation(aerospikeCluster, timeout)
+				}
+				return errors.NewRequeueError(migrationsPollPeriod, "waiting for pod %s to join the cluster and for migrations to finish", meta.Key(pod))
+			}
+			return r.finishPodOperation(ctx, aerospikeCluster, desiredPods)
+
+		default:
+			// should not happen, but make sure we don't get stuck
+			return fmt.Errorf("unknown step %q of %s operation on pod with index %d", op.Step, op.Type, op.PodIndex)
+		}
+	}
+}
+
+// haltPodOperation signals that the operation recorded in the status of the
+// cluster cannot proceed because the cluster did not stabilize within the
+// specified timeout. the operation is resumed once the cluster is stable.
+func (r *AerospikeClusterReconciler) haltPodOperation(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, timeout time.Duration) error {
+	op := aerospikeCluster.Status.PodOperation
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonClusterNotStable,
+		"the cluster did not become stable within %s, halting the %s operation on pod with index %d", timeout, op.Type, op.PodIndex)
+	return errors.NewUnstableClusterError("the cluster did not become stable within %s, halting the %s operation on pod with index %d", timeout, op.Type, op.PodIndex)
+}
+
+// haltPodQuiescing signals that the pod being quiesced by the operation in
+// progress did not hand off its master partitions within the specified
+// timeout. the pod is quiesced again after backing off, and the cluster is
+// reported as degraded in the meantime based on the recorded failures.
+func (r *AerospikeClusterReconciler) haltPodQuiescing(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod, timeout time.Duration) error {
+	op := aerospikeCluster.Status.PodOperation
+	backoff := getQuiesceBackoffPeriod(op.QuiesceFailures)
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonClusterNotStable,
+		"pod %s did not hand off its master partitions within %s, backing off for %s", meta.Key(pod), timeout, backoff)
+	return errors.NewRequeueError(backoff, "pod %s did not hand off its master partitions within %s, backing off for %s", meta.Key(pod), timeout, backoff)
+}
+
+// cancelPodQuiescing puts the pod being quiesced by the operation in progress
+// back into service, and moves the operation back to waiting for the cluster
+// to be stable so that the pod is quiesced again after backing off.
+func (r *AerospikeClusterReconciler) cancelPodQuiescing(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
+	if pod != nil && pod.DeletionTimestamp == nil {
+		if err := r.undoQuiescePod(ctx, aerospikeCluster, pod); err != nil {
+			return err
+		}
+	}
+	op := aerospikeCluster.Status.PodOperation.DeepCopy()
+	op.Step = common.PodOperationStepWaitingForMigrations
+	op.StepStartTime = metav1.NewTime(time.Now())
+	op.QuiesceFailures++
+	return r.setPodOperation(ctx, aerospikeCluster, op)
+}
+
+// getQuiesceBackoffPeriod returns how long to wait before quiescing a node
+// which failed to hand off its master partitions in time the specified number
+// of times.
+func getQuiesceBackoffPeriod(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	backoff := quiesceBackoffPeriod
+	for i := 1; i < failures && backoff < maxQuiesceBackoffPeriod; i++ {
+		backoff *= 2
+	}
+	if backoff > maxQuiesceBackoffPeriod {
+		return maxQuiesceBackoffPeriod
+	}
+	return backoff
+}
+
+// getStabilizationTimeout returns how long to wait for the specified cluster
+// to become stable before operating on the next pod.
+func getStabilizationTimeout(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) time.Duration {
+	if rollout := aerospikeCluster.Spec.Rollout; rollout != nil && rollout.StabilizationTimeout != nil {
+		return rollout.StabilizationTimeout.Duration
+	}
+	return waitStabilizationTimeout
+}
+
+// getQuiesceTimeout returns how long to wait for a quiesced node of the
+// specified cluster to hand off its master partitions.
+func getQuiesceTimeout(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) time.Duration {
+	if rollout := aerospikeCluster.Spec.Rollout; rollout != nil && rollout.QuiesceTimeout != nil {
+		return rollout.QuiesceTimeout.Duration
+	}
+	return waitQuiesceTimeout
+}
+
+// finishPodOperation signals that the operation recorded in the status of the
+// cluster has finished, and removes it from the status.
+func (r *AerospikeClusterReconciler) finishPodOperation(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desiredPods map[int]*aerospikev1alpha2.NodeGroupSpec) error {
+	op := aerospikeCluster.Status.PodOperation
+	if err := r.setPodOperation(ctx, aerospikeCluster, nil); err != nil {
+		return err
+	}
+
+	switch op.Type {
+	case common.PodOperationTypeUpgrade:
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeUpgradeFinished,
+			"upgraded pod with index %d to version %s", op.PodIndex, aerospikeCluster.Spec.Version)
+	case common.PodOperationTypeMigrateStorage:
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonStorageMigrationFinished,
+			"storage of the pod with index %d migrated", op.PodIndex)
+	case common.PodOperationTypeReplace:
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeReplacementFinished,
+			"pod with index %d replaced", op.PodIndex)
+	case common.PodOperationTypeRestart:
+		// report the progress of a change to the replication factor
+		if isReplicationFactorChangeInProgress(aerospikeCluster) {
+			indexes := sortedPodIndexes(desiredPods)
+			for n, i := range indexes {
+				if i == op.PodIndex {
+					if err := r.signalReplicationFactorChangeProgress(ctx, aerospikeCluster, n+1, len(indexes)); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.PodIndex:         op.PodIndex,
+	}).Infof("%s operation finished", op.Type)
+	return nil
+}
+
+// refreshPodOperation reads the operation in progress (if any) from the
+// kubernetes api, as the copy of the cluster held by the lister may not
+// reflect the latest changes to its status.
+func (r *AerospikeClusterReconciler) refreshPodOperation(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	current, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(aerospikeCluster.Namespace).Get(ctx, aerospikeCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	aerospikeCluster.Status.PodOperation = current.Status.PodOperation
+	return nil
+}
+
+// setPodOperationStep records the start of the specified step of the
+// operation in progress.
+func (r *AerospikeClusterReconciler) setPodOperationStep(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, step common.PodOperationStep) error {
+	op := aerospikeCluster.Status.PodOperation.DeepCopy()
+	op.Step = step
+	op.StepStartTime = metav1.NewTime(time.Now())
+	if err := r.setPodOperation(ctx, aerospikeCluster, op); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.PodIndex:         op.PodIndex,
+	}).Debugf("%s operation entered step %s", op.Type, step)
+	return nil
+}
+
+// setPodOperation records the specified operation in the status of the
+// cluster.
+func (r *AerospikeClusterReconciler) setPodOperation(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, op *aerospikev1alpha2.PodOperation) error {
+	oldCluster := aerospikeCluster.DeepCopy()
+	aerospikeCluster.Status.PodOperation = op
+	return r.patchCluster(ctx, oldCluster, aerospikeCluster)
+}
@@ -0,0 +1,653 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	fakeversioned "github.com/travelaudience/aerospike-operator/pkg/client/clientset/versioned/fake"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+const (
+	testClusterName = "as-cluster-0"
+	testNamespace   = "default"
+	testVersion     = "4.5.0.5"
+	// testPodIndex is the index of the pod being operated on
+	testPodIndex = 1
+)
+
+// fakeNode holds the state reported by a fake aerospike node. zero values are
+// replaced by sensible defaults.
+type fakeNode struct {
+	build         string
+	clusterSize   int
+	clusterKey    string
+	migrations    int
+	masterObjects int
+	quiesced      bool
+}
+
+// fakeInfo answers info commands on behalf of the fake aerospike nodes running
+// on the pods with the specified indexes, and records the commands it runs.
+type fakeInfo struct {
+	sync.Mutex
+	nodes    map[string]*fakeNode
+	commands []string
+}
+
+func newFakeInfo(nodes map[int]*fakeNode, pods []*corev1.Pod) *fakeInfo {
+	res := &fakeInfo{nodes: make(map[string]*fakeNode)}
+	for _, pod := range pods {
+		node, ok := nodes[podIndex(pod)]
+		if !ok {
+			node = &fakeNode{}
+		}
+		if node.build == "" {
+			node.build = testVersion
+		}
+		if node.clusterSize == 0 {
+			node.clusterSize = len(pods)
+		}
+		if node.clusterKey == "" {
+			node.clusterKey = "A1B2C3D4E5F6"
+		}
+		res.nodes[pod.Status.PodIP] = node
+	}
+	return res
+}
+
+func (f *fakeInfo) requestInfo(_ context.Context, host string, _ int, commands ...string) (map[string]string, error) {
+	f.Lock()
+	defer f.Unlock()
+	node, ok := f.nodes[host]
+	if !ok {
+		return nil, fmt.Errorf("no aerospike node at %s", host)
+	}
+	res := make(map[string]string, len(commands))
+	for _, command := range commands {
+		f.commands = append(f.commands, fmt.Sprintf("%s %s", host, command))
+		switch command {
+		case "build":
+			res[command] = node.build
+		case "statistics":
+			res[command] = fmt.Sprintf("cluster_size=%d;cluster_key=%s;migrate_partitions_remaining=%d", node.clusterSize, node.clusterKey, node.migrations)
+		case "namespaces":
+			res[command] = "test"
+		case "namespace/test":
+			res[command] = fmt.Sprintf("pending_quiesce=%t;effective_is_quiesced=%t;master_objects=%d", node.quiesced, node.quiesced, node.masterObjects)
+		case "quiesce:":
+			node.quiesced = true
+			res[command] = "ok"
+		case "quiesce-undo:":
+			node.quiesced = false
+			res[command] = "ok"
+		case fmt.Sprintf("cluster-stable:size=%d;ignore-migrations=false", node.clusterSize):
+			if node.migrations > 0 {
+				res[command] = "ERROR::unstable-cluster"
+			} else {
+				res[command] = node.clusterKey
+			}
+		default:
+			if strings.HasPrefix(command, "cluster-stable:") {
+				res[command] = "ERROR::cluster-not-specified-size"
+			} else {
+				res[command] = "ok"
+			}
+		}
+	}
+	return res, nil
+}
+
+// hasCommand returns whether a command starting with the specified prefix has
+// been run against the pod with the specified index.
+func (f *fakeInfo) hasCommand(index int, prefix string) bool {
+	f.Lock()
+	defer f.Unlock()
+	for _, command := range f.commands {
+		if strings.HasPrefix(command, fmt.Sprintf("%s %s", testPodIP(index), prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func testPodIP(index int) string {
+	return fmt.Sprintf("10.0.0.%d", index)
+}
+
+func testPod(index int, ready bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", testClusterName, index),
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				selectors.LabelAppKey:     selectors.LabelAppVal,
+				selectors.LabelClusterKey: testClusterName,
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: testPodIP(index),
+		},
+	}
+	if ready {
+		pod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		}
+	}
+	return pod
+}
+
+// testPods returns a ready pod for every index other than testPodIndex, and
+// the specified pod (if any) in its place.
+func testPods(target *corev1.Pod) []*corev1.Pod {
+	res := []*corev1.Pod{testPod(0, true), testPod(2, true)}
+	if target != nil {
+		res = append(res, target)
+	}
+	return res
+}
+
+func isRequeueError(err error) bool {
+	_, ok := errors.AsRequeueError(err)
+	return ok
+}
+
+func isUnstableClusterError(err error) bool {
+	_, ok := errors.AsUnstableClusterError(err)
+	return ok
+}
+
+func isOtherError(err error) bool {
+	return err != nil && !isRequeueError(err) && !isUnstableClusterError(err) && err != errors.PodUpgradeFailed
+}
+
+func isNil(err error) bool {
+	return err == nil
+}
+
+func isPodUpgradeFailed(err error) bool {
+	return err == errors.PodUpgradeFailed
+}
+
+func hasAction(client *kubefake.Clientset, verb, resource string) bool {
+	for _, action := range client.Actions() {
+		if action.GetVerb() == verb && action.GetResource().Resource == resource {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResumePodOperation(t *testing.T) {
+	tests := []struct {
+		name string
+		// the operation recorded in the status of the cluster
+		opType          common.PodOperationType
+		step            common.PodOperationStep
+		stepAge         time.Duration
+		quiesceFailures int
+		// the pods known to the lister, and the state of their nodes
+		pods  []*corev1.Pod
+		nodes map[int]*fakeNode
+		// pods which exist but have not been observed by the lister yet
+		unobservedPods []*corev1.Pod
+		// the expected outcome
+		wantErr             func(error) bool
+		wantStep            common.PodOperationStep
+		wantQuiesceFailures int
+		wantDeleted         bool
+		wantCreated         bool
+		wantCommands        []string
+		wantNoCommands      []string
+	}{
+		{
+			name:     "waits for migrations to finish",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepWaitingForMigrations,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			nodes:    map[int]*fakeNode{2: {migrations: 10}},
+			wantErr:  isRequeueError,
+			wantStep: common.PodOperationStepWaitingForMigrations,
+		},
+		{
+			name:     "waits for the nodes to agree on the cluster size",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepWaitingForMigrations,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			nodes:    map[int]*fakeNode{0: {clusterSize: 2}},
+			wantErr:  isRequeueError,
+			wantStep: common.PodOperationStepWaitingForMigrations,
+		},
+		{
+			name:           "checks cluster stability using cluster-stable",
+			opType:         common.PodOperationTypeRestart,
+			step:           common.PodOperationStepWaitingForMigrations,
+			stepAge:        time.Minute,
+			pods:           testPods(testPod(testPodIndex, true)),
+			nodes:          map[int]*fakeNode{2: {migrations: 10}},
+			wantErr:        isRequeueError,
+			wantStep:       common.PodOperationStepWaitingForMigrations,
+			wantCommands:   []string{"cluster-stable:"},
+			wantNoCommands: []string{"statistics"},
+		},
+		{
+			name:    "falls back to statistics on older versions of aerospike",
+			opType:  common.PodOperationTypeRestart,
+			step:    common.PodOperationStepWaitingForMigrations,
+			stepAge: time.Minute,
+			pods:    testPods(testPod(testPodIndex, true)),
+			nodes: map[int]*fakeNode{
+				0:            {build: "4.2.0.10"},
+				testPodIndex: {build: "4.2.0.10"},
+				2:            {build: "4.2.0.10", migrations: 10},
+			},
+			wantErr:        isRequeueError,
+			wantStep:       common.PodOperationStepWaitingForMigrations,
+			wantCommands:   []string{"statistics"},
+			wantNoCommands: []string{"cluster-stable:"},
+		},
+		{
+			name:     "halts when the cluster does not become stable in time",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepWaitingForMigrations,
+			stepAge:  2 * time.Hour,
+			pods:     testPods(testPod(testPodIndex, true)),
+			nodes:    map[int]*fakeNode{0: {clusterKey: "F6E5D4C3B2A1"}},
+			wantErr:  isUnstableClusterError,
+			wantStep: common.PodOperationStepWaitingForMigrations,
+		},
+		{
+			name:         "quiesces the pod once the cluster is stable",
+			opType:       common.PodOperationTypeRestart,
+			step:         common.PodOperationStepWaitingForMigrations,
+			stepAge:      time.Minute,
+			pods:         testPods(testPod(testPodIndex, true)),
+			nodes:        map[int]*fakeNode{testPodIndex: {masterObjects: 100}},
+			wantErr:      isRequeueError,
+			wantStep:     common.PodOperationStepQuiescing,
+			wantCommands: []string{"quiesce:", "recluster:"},
+		},
+		{
+			name:           "deletes a pod which is not ready without quiescing it",
+			opType:         common.PodOperationTypeRestart,
+			step:           common.PodOperationStepWaitingForMigrations,
+			stepAge:        time.Minute,
+			pods:           testPods(testPod(testPodIndex, false)),
+			nodes:          map[int]*fakeNode{0: {clusterSize: 2}, 2: {clusterSize: 2}},
+			wantErr:        isRequeueError,
+			wantStep:       common.PodOperationStepDeleting,
+			wantDeleted:    true,
+			wantNoCommands: []string{"quiesce:"},
+		},
+		{
+			name:        "deletes the pod once it has handed off its master partitions",
+			opType:      common.PodOperationTypeRestart,
+			step:        common.PodOperationStepQuiescing,
+			stepAge:     time.Minute,
+			pods:        testPods(testPod(testPodIndex, true)),
+			nodes:       map[int]*fakeNode{testPodIndex: {quiesced: true}},
+			wantErr:     isRequeueError,
+			wantStep:    common.PodOperationStepDeleting,
+			wantDeleted: true,
+		},
+		{
+			name:                "puts the pod back into service when quiescing times out",
+			opType:              common.PodOperationTypeRestart,
+			step:                common.PodOperationStepQuiescing,
+			stepAge:             2 * time.Hour,
+			pods:                testPods(testPod(testPodIndex, true)),
+			nodes:               map[int]*fakeNode{testPodIndex: {quiesced: true, masterObjects: 100}},
+			wantErr:             isRequeueError,
+			wantStep:            common.PodOperationStepWaitingForMigrations,
+			wantQuiesceFailures: 1,
+			wantCommands:        []string{"quiesce-undo:", "recluster:"},
+		},
+		{
+			name:                "backs off before quiescing the pod again",
+			opType:              common.PodOperationTypeRestart,
+			step:                common.PodOperationStepWaitingForMigrations,
+			stepAge:             time.Minute,
+			quiesceFailures:     1,
+			pods:                testPods(testPod(testPodIndex, true)),
+			wantErr:             isRequeueError,
+			wantStep:            common.PodOperationStepWaitingForMigrations,
+			wantQuiesceFailures: 1,
+			wantNoCommands:      []string{"cluster-stable:", "statistics", "quiesce:"},
+		},
+		{
+			name:                "quiesces the pod again after backing off",
+			opType:              common.PodOperationTypeRestart,
+			step:                common.PodOperationStepWaitingForMigrations,
+			stepAge:             10 * time.Minute,
+			quiesceFailures:     1,
+			pods:                testPods(testPod(testPodIndex, true)),
+			nodes:               map[int]*fakeNode{testPodIndex: {masterObjects: 100}},
+			wantErr:             isRequeueError,
+			wantStep:            common.PodOperationStepQuiescing,
+			wantQuiesceFailures: 1,
+			wantCommands:        []string{"quiesce:"},
+		},
+		{
+			name:                "waits for the cluster to be stable after the maximum backoff",
+			opType:              common.PodOperationTypeRestart,
+			step:                common.PodOperationStepWaitingForMigrations,
+			stepAge:             time.Hour + time.Minute,
+			quiesceFailures:     5,
+			pods:                testPods(testPod(testPodIndex, true)),
+			nodes:               map[int]*fakeNode{2: {migrations: 10}},
+			wantErr:             isRequeueError,
+			wantStep:            common.PodOperationStepWaitingForMigrations,
+			wantQuiesceFailures: 5,
+			wantNoCommands:      []string{"quiesce:"},
+		},
+		{
+			name:                "halts when the cluster does not become stable in time after the maximum backoff",
+			opType:              common.PodOperationTypeRestart,
+			step:                common.PodOperationStepWaitingForMigrations,
+			stepAge:             2*time.Hour + time.Minute,
+			quiesceFailures:     5,
+			pods:                testPods(testPod(testPodIndex, true)),
+			nodes:               map[int]*fakeNode{2: {migrations: 10}},
+			wantErr:             isUnstableClusterError,
+			wantStep:            common.PodOperationStepWaitingForMigrations,
+			wantQuiesceFailures: 5,
+			wantNoCommands:      []string{"quiesce:"},
+		},
+		{
+			name:     "waits for the pod to be deleted",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepDeleting,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			wantErr:  isRequeueError,
+			wantStep: common.PodOperationStepDeleting,
+		},
+		{
+			name:     "fails when the pod is not deleted in time",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepDeleting,
+			stepAge:  10 * time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			wantErr:  isUnstableClusterError,
+			wantStep: common.PodOperationStepDeleting,
+		},
+		{
+			name:         "re-creates the pod once it is gone",
+			opType:       common.PodOperationTypeRestart,
+			step:         common.PodOperationStepDeleting,
+			stepAge:      time.Minute,
+			pods:         testPods(nil),
+			wantErr:      isRequeueError,
+			wantStep:     common.PodOperationStepCreating,
+			wantCreated:  true,
+			wantCommands: []string{"tip-clear:", "services-alumni-reset"},
+		},
+		{
+			name:     "finishes a delete operation once the pod is gone",
+			opType:   common.PodOperationTypeDelete,
+			step:     common.PodOperationStepDeleting,
+			stepAge:  time.Minute,
+			pods:     testPods(nil),
+			wantErr:  isNil,
+			wantStep: "",
+		},
+		{
+			name:     "waits for the new pod to become ready",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepCreating,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, false)),
+			wantErr:  isRequeueError,
+			wantStep: common.PodOperationStepCreating,
+		},
+		{
+			name:        "re-creates a pod which does not become ready in time",
+			opType:      common.PodOperationTypeRestart,
+			step:        common.PodOperationStepCreating,
+			stepAge:     4 * time.Hour,
+			pods:        testPods(testPod(testPodIndex, false)),
+			wantErr:     isRequeueError,
+			wantStep:    common.PodOperationStepCreating,
+			wantDeleted: true,
+		},
+		{
+			name:           "waits for a pod created by a previous attempt to be observed",
+			opType:         common.PodOperationTypeRestart,
+			step:           common.PodOperationStepCreating,
+			stepAge:        time.Minute,
+			pods:           testPods(nil),
+			unobservedPods: []*corev1.Pod{testPod(testPodIndex, false)},
+			wantErr:        isRequeueError,
+			wantStep:       common.PodOperationStepCreating,
+			// the pod is created again, which fails as it already exists
+			wantCreated: true,
+		},
+		{
+			name:     "finishes a restart once the new pod is ready",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepCreating,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			wantErr:  isNil,
+			wantStep: "",
+		},
+		{
+			name:     "abandons an upgrade when the new pod runs a different version",
+			opType:   common.PodOperationTypeUpgrade,
+			step:     common.PodOperationStepCreating,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			nodes:    map[int]*fakeNode{testPodIndex: {build: "4.2.0.10"}},
+			wantErr:  isPodUpgradeFailed,
+			wantStep: "",
+		},
+		{
+			name:     "waits for a replaced pod to settle",
+			opType:   common.PodOperationTypeReplace,
+			step:     common.PodOperationStepCreating,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			nodes:    map[int]*fakeNode{testPodIndex: {migrations: 10}},
+			wantErr:  isRequeueError,
+			wantStep: common.PodOperationStepSettling,
+		},
+		{
+			name:     "finishes a replacement once the cluster is stable",
+			opType:   common.PodOperationTypeReplace,
+			step:     common.PodOperationStepSettling,
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			wantErr:  isNil,
+			wantStep: "",
+		},
+		{
+			name:        "re-creates a pod which disappears while settling",
+			opType:      common.PodOperationTypeReplace,
+			step:        common.PodOperationStepSettling,
+			stepAge:     time.Minute,
+			pods:        testPods(nil),
+			wantErr:     isRequeueError,
+			wantStep:    common.PodOperationStepCreating,
+			wantCreated: true,
+		},
+		{
+			name:           "re-creates a pod which disappears while waiting for migrations",
+			opType:         common.PodOperationTypeRestart,
+			step:           common.PodOperationStepWaitingForMigrations,
+			stepAge:        time.Minute,
+			pods:           testPods(nil),
+			wantErr:        isRequeueError,
+			wantStep:       common.PodOperationStepCreating,
+			wantCreated:    true,
+			wantNoCommands: []string{"quiesce:"},
+		},
+		{
+			name:     "re-creates a pod which disappears while being quiesced",
+			opType:   common.PodOperationTypeRestart,
+			step:     common.PodOperationStepQuiescing,
+			stepAge:  time.Minute,
+			pods:     testPods(nil),
+			wantErr:  isRequeueError,
+			wantStep: common.PodOperationStepCreating,
+			// the pod is gone, so nothing is deleted
+			wantCreated: true,
+		},
+		{
+			name:     "fails on an unknown step",
+			opType:   common.PodOperationTypeRestart,
+			step:     "Unknown",
+			stepAge:  time.Minute,
+			pods:     testPods(testPod(testPodIndex, true)),
+			wantErr:  isOtherError,
+			wantStep: "Unknown",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testClusterName,
+					Namespace: testNamespace,
+				},
+				Spec: aerospikev1alpha2.AerospikeClusterSpec{
+					Version:   testVersion,
+					NodeCount: 3,
+				},
+				Status: aerospikev1alpha2.AerospikeClusterStatus{
+					PodOperation: &aerospikev1alpha2.PodOperation{
+						Type:            test.opType,
+						PodIndex:        testPodIndex,
+						Step:            test.step,
+						StepStartTime:   metav1.NewTime(time.Now().Add(-test.stepAge)),
+						QuiesceFailures: test.quiesceFailures,
+					},
+				},
+			}
+			desiredPods := map[int]*aerospikev1alpha2.NodeGroupSpec{0: nil, 1: nil, 2: nil}
+			if test.opType == common.PodOperationTypeDelete {
+				delete(desiredPods, testPodIndex)
+			}
+
+			podsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			objects := make([]runtime.Object, 0, len(test.pods)+len(test.unobservedPods))
+			for _, pod := range test.pods {
+				assert.NoError(t, podsIndexer.Add(pod))
+				objects = append(objects, pod)
+			}
+			for _, pod := range test.unobservedPods {
+				objects = append(objects, pod)
+			}
+			kubeClient := kubefake.NewSimpleClientset(objects...)
+			aerospikeClient := fakeversioned.NewSimpleClientset(aerospikeCluster.DeepCopy())
+
+			info := newFakeInfo(test.nodes, test.pods)
+			defer func(f func(context.Context, string, int, ...string) (map[string]string, error)) {
+				requestInfo = f
+			}(requestInfo)
+			requestInfo = info.requestInfo
+
+			r := &AerospikeClusterReconciler{
+				kubeclientset:      kubeClient,
+				aerospikeclientset: aerospikeClient,
+				podsLister:         listersv1.NewPodLister(podsIndexer),
+				pvcsLister:         listersv1.NewPersistentVolumeClaimLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})),
+				recorder:           record.NewFakeRecorder(100),
+			}
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testClusterName,
+					Namespace: testNamespace,
+				},
+			}
+
+			err := r.resumePodOperation(context.Background(), aerospikeCluster, configMap, nil, desiredPods)
+			assert.True(t, test.wantErr(err), "unexpected error: %v", err)
+
+			op := aerospikeCluster.Status.PodOperation
+			if test.wantStep == "" {
+				assert.Nil(t, op)
+			} else if assert.NotNil(t, op) {
+				assert.Equal(t, test.wantStep, op.Step)
+				assert.Equal(t, test.wantQuiesceFailures, op.QuiesceFailures)
+				if test.step != test.wantStep || test.stepAge > createPodTimeout {
+					assert.True(t, time.Since(op.StepStartTime.Time) < time.Minute, "the start time of the step was not reset")
+				}
+			}
+			// the operation must have been recorded in the resource too
+			res, err := aerospikeClient.AerospikeV1alpha2().AerospikeClusters(testNamespace).Get(context.Background(), testClusterName, metav1.GetOptions{})
+			if assert.NoError(t, err) {
+				assert.Equal(t, op == nil, res.Status.PodOperation == nil)
+				if op != nil && res.Status.PodOperation != nil {
+					assert.Equal(t, op.Step, res.Status.PodOperation.Step)
+				}
+			}
+
+			assert.Equal(t, test.wantDeleted, hasAction(kubeClient, "delete", "pods"), "unexpected pod deletion")
+			assert.Equal(t, test.wantCreated, hasAction(kubeClient, "create", "pods"), "unexpected pod creation")
+			for _, command := range test.wantCommands {
+				found := false
+				for _, pod := range test.pods {
+					found = found || info.hasCommand(podIndex(pod), command)
+				}
+				assert.True(t, found, "%q was not run", command)
+			}
+			for _, command := range test.wantNoCommands {
+				for _, pod := range test.pods {
+					assert.False(t, info.hasCommand(podIndex(pod), command), "%q was run on pod %s", command, pod.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestGetQuiesceBackoffPeriod(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, quiesceBackoffPeriod},
+		{2, 2 * quiesceBackoffPeriod},
+		{3, 4 * quiesceBackoffPeriod},
+		{100, maxQuiesceBackoffPeriod},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, getQuiesceBackoffPeriod(test.failures), "failures: %d", test.failures)
+	}
+}
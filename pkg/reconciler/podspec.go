@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
+)
+
+// podSpec holds the settings used to generate the spec of a pod which must be
+// rolled out to existing pods when changed. the aerospike configuration and
+// version, the list of mesh seeds and the persistent volume claims are left
+// out, as changes to these are handled separately.
+type podSpec struct {
+	ServerRepository string                             `json:"serverRepository"`
+	ToolsImage       string                             `json:"toolsImage"`
+	Images           *aerospikev1alpha2.ImagesSpec      `json:"images,omitempty"`
+	Resources        corev1.ResourceRequirements        `json:"resources"`
+	NodeSelector     map[string]string                  `json:"nodeSelector,omitempty"`
+	RackID           *int32                             `json:"rackId,omitempty"`
+	Tolerations      []corev1.Toleration                `json:"tolerations,omitempty"`
+	PodTemplate      *aerospikev1alpha2.PodTemplateSpec `json:"podTemplate,omitempty"`
+	Monitoring       *aerospikev1alpha2.MonitoringSpec  `json:"monitoring,omitempty"`
+}
+
+// computePodSpecHash returns the hash of the settings used to generate the spec
+// of the pod with the specified index belonging to the specified node group.
+func computePodSpecHash(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int, nodeGroup *aerospikev1alpha2.NodeGroupSpec) string {
+	// the prometheus operator settings do not affect the pods
+	monitoring := aerospikeCluster.Spec.Monitoring
+	if monitoring != nil && monitoring.PrometheusOperator != nil {
+		monitoring = monitoring.DeepCopy()
+		monitoring.PrometheusOperator = nil
+	}
+	rack := aerospikeCluster.Spec.GetRackForPodIndex(index)
+	spec := podSpec{
+		ServerRepository: images.AerospikeServerRepository,
+		ToolsImage:       images.Tools(aerospikeCluster.Spec.Images),
+		Images:           aerospikeCluster.Spec.Images,
+		Resources:        computeAerospikeServerResources(aerospikeCluster, nodeGroup),
+		NodeSelector:     computeNodeSelector(aerospikeCluster, nodeGroup, rack),
+		Tolerations:      computeTolerations(aerospikeCluster, nodeGroup),
+		PodTemplate:      aerospikeCluster.Spec.PodTemplate,
+		Monitoring:       monitoring,
+	}
+	if rack != nil {
+		spec.RackID = &rack.ID
+	}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		// should not happen, as every field can be encoded as json
+		return ""
+	}
+	return asstrings.Hash(string(b))
+}
+
+// isPodSpecOutdated returns whether the spec of the specified pod differs from
+// the one that would be generated for it now, in which case it must be
+// restarted. pods created by previous versions of aerospike-operator do not
+// hold the hash, and are not considered to be outdated (see ensurePodSpecHash).
+func isPodSpecOutdated(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) bool {
+	// pods adopted by a node group must be re-created in order to be labeled
+	// accordingly
+	if _, ok := pod.Labels[selectors.LabelNodeGroupKey]; !ok && len(aerospikeCluster.Spec.NodeGroups) > 0 {
+		return true
+	}
+	hash, ok := pod.Annotations[podSpecHashAnnotation]
+	if !ok {
+		return false
+	}
+	return hash != computePodSpecHash(aerospikeCluster, podIndex(pod), getPodNodeGroup(aerospikeCluster, pod))
+}
+
+// podNeedsRackChange returns whether the specified pod must be moved to a rack
+// in a different failure domain than the one it currently runs in, in which
+// case it must be given new persistent volume claims, as the current ones may
+// not be usable in the new failure domain.
+func podNeedsRackChange(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) bool {
+	var current *aerospikev1alpha2.RackSpec
+	if id, ok := pod.Annotations[rackIdAnnotation]; ok {
+		for i := range aerospikeCluster.Spec.Racks {
+			if strconv.Itoa(int(aerospikeCluster.Spec.Racks[i].ID)) == id {
+				current = &aerospikeCluster.Spec.Racks[i]
+				break
+			}
+		}
+		// should not happen, as racks cannot be removed from an existing
+		// cluster
+		if current == nil {
+			return false
+		}
+	}
+	return !current.IsInSameFailureDomainAs(aerospikeCluster.Spec.GetRackForPodIndex(podIndex(pod)))
+}
+
+// ensurePodSpecHash stamps the hash of the current settings onto the specified
+// pod if it does not hold one (i.e. if it was created by a previous version of
+// aerospike-operator), so that upgrading aerospike-operator does not cause
+// every pod to be restarted.
+func (r *AerospikeClusterReconciler) ensurePodSpecHash(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) (*corev1.Pod, error) {
+	if _, ok := pod.Annotations[podSpecHashAnnotation]; ok {
+		return pod, nil
+	}
+	newPod := pod.DeepCopy()
+	if newPod.Annotations == nil {
+		newPod.Annotations = make(map[string]string)
+	}
+	newPod.Annotations[podSpecHashAnnotation] = computePodSpecHash(aerospikeCluster, podIndex(pod), getPodNodeGroup(aerospikeCluster, pod))
+	return r.patchPod(ctx, pod, newPod)
+}
+
+// isPodRestartRequested returns whether a rolling restart has been requested
+// (by changing .spec.restartGeneration) since the specified pod was created.
+// pods created by previous versions of aerospike-operator do not hold the
+// annotation, and are only restarted once .spec.restartGeneration is set.
+func isPodRestartRequested(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) bool {
+	restartGeneration, ok := pod.Annotations[restartGenerationAnnotation]
+	if !ok {
+		return aerospikeCluster.Spec.RestartGeneration != 0
+	}
+	return restartGeneration != strconv.FormatInt(aerospikeCluster.Spec.RestartGeneration, 10)
+}
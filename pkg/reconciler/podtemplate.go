@@ -0,0 +1,55 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+// applyPodTemplate merges the settings in the pod template of the specified
+// cluster into the specified pod. labels and annotations managed by
+// aerospike-operator take precedence over the user-provided ones.
+func applyPodTemplate(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) {
+	podTemplate := aerospikeCluster.Spec.PodTemplate
+	if podTemplate == nil {
+		return
+	}
+	for key, value := range podTemplate.Labels {
+		if _, ok := pod.Labels[key]; !ok {
+			pod.Labels[key] = value
+		}
+	}
+	for key, value := range podTemplate.Annotations {
+		// requesting the replacement of every pod would cause pods to be
+		// replaced over and over again
+		if key == ReplaceAnnotation {
+			continue
+		}
+		if _, ok := pod.Annotations[key]; !ok {
+			pod.Annotations[key] = value
+		}
+	}
+	pod.Spec.PriorityClassName = podTemplate.PriorityClassName
+	pod.Spec.ServiceAccountName = podTemplate.ServiceAccountName
+	pod.Spec.SecurityContext = podTemplate.SecurityContext
+	pod.Spec.TopologySpreadConstraints = podTemplate.TopologySpreadConstraints
+	pod.Spec.Volumes = append(pod.Spec.Volumes, podTemplate.Volumes...)
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, podTemplate.InitContainers...)
+	pod.Spec.Containers = append(pod.Spec.Containers, podTemplate.Containers...)
+}
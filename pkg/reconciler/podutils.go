@@ -23,17 +23,25 @@ import (
 	"strings"
 	"time"
 
-	as "github.com/aerospike/aerospike-client-go"
 	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	watchapi "k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/watch"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+var (
+	// quiesceMinVersion is the earliest version of aerospike supporting the
+	// quiesce info command
+	quiesceMinVersion = versioning.Version{Major: 4, Minor: 3, Patch: 1, Revision: 3}
+	// clusterStableMinVersion is the earliest version of aerospike supporting
+	// the cluster-stable info command
+	clusterStableMinVersion = versioning.Version{Major: 4, Minor: 3, Patch: 0, Revision: 2}
+	// requestInfo runs info commands against the aerospike node at the
+	// specified address (and is replaced in tests)
+	requestInfo = asutils.RequestInfo
 )
 
 type byIndex []*v1.Pod
@@ -64,6 +72,16 @@ func isPodRunningAndReady(pod *v1.Pod) bool {
 	return pod.Status.Phase == v1.PodRunning && podutil.IsPodReady(pod)
 }
 
+// podReadySince returns the time at which the specified pod last became ready,
+// or the zero time if it is not ready.
+func podReadySince(pod *v1.Pod) time.Time {
+	condition := podutil.GetPodReadyCondition(pod.Status)
+	if condition == nil || condition.Status != v1.ConditionTrue {
+		return time.Time{}
+	}
+	return condition.LastTransitionTime.Time
+}
+
 // isPodInFailureState attempts to checks if the specified pod has reached an error condition from which it is not
 // expected to recover.
 func isPodInFailureState(pod *v1.Pod) bool {
@@ -92,96 +110,168 @@ func isPodInFailureState(pod *v1.Pod) bool {
 	return false
 }
 
+// isPodReplacementRequested returns whether the user has requested the
+// specified pod to be re-created with new persistent volume claims.
+func isPodReplacementRequested(pod *v1.Pod) bool {
+	return pod.Annotations[ReplaceAnnotation] == "true"
+}
+
 // isImageError indicated whether the specified reason corresponds to an error while pulling or inspecting a container
 // image.
 func isImageError(reason string) bool {
 	return reason == ReasonErrImagePull || reason == ReasonImageInspectError || reason == ReasonImagePullBackOff || reason == ReasonRegistryUnavailable
 }
 
-func (r *AerospikeClusterReconciler) waitForPodCondition(pod *v1.Pod, fn watch.ConditionFunc, timeout time.Duration) error {
-	fs := selectors.ObjectByCoordinates(pod.Namespace, pod.Name)
-	lw := &cache.ListWatch{
-		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-			options.FieldSelector = fs.String()
-			return r.kubeclientset.CoreV1().Pods(pod.Namespace).List(context.TODO(), options)
-		},
-		WatchFunc: func(options metav1.ListOptions) (watchapi.Interface, error) {
-			options.FieldSelector = fs.String()
-			return r.kubeclientset.CoreV1().Pods(pod.Namespace).Watch(context.TODO(), options)
-		},
-	}
-	ctx, cfn := context.WithTimeout(context.Background(), timeout)
-	defer cfn()
-	last, err := watch.UntilWithSync(ctx, lw, &v1.Pod{}, nil, fn)
-	if err != nil {
-		return err
+// migrationsRemaining returns the number of partitions an aerospike node has
+// left to send or to receive according to the specified statistics.
+func migrationsRemaining(stats map[string]string) int64 {
+	var res int64
+	keys := []string{"migrate_tx_partitions_remaining", "migrate_rx_partitions_remaining"}
+	// older versions of aerospike report a single counter
+	if _, ok := stats[keys[0]]; !ok {
+		keys = []string{"migrate_partitions_remaining"}
 	}
-	if last == nil {
-		return fmt.Errorf("no events received for pod %s", meta.Key(pod))
+	for _, key := range keys {
+		if v, err := strconv.ParseInt(stats[key], 10, 64); err == nil {
+			res += v
+		}
 	}
-	return nil
+	return res
 }
 
-func podHasMigrationsInProgress(pod *v1.Pod) (bool, error) {
-	client, err := as.NewClient(pod.Status.PodIP, ServicePort)
+func runInfoCommandOnPod(ctx context.Context, pod *v1.Pod, commands ...string) (map[string]string, error) {
+	return requestInfo(ctx, pod.Status.PodIP, ServicePort, commands...)
+}
+
+// getClusterSize returns the size of the cluster as seen by the aerospike node
+// running on the specified pod.
+func getClusterSize(ctx context.Context, pod *v1.Pod) (int, error) {
+	res, err := runInfoCommandOnPod(ctx, pod, "statistics")
 	if err != nil {
-		return false, err
+		return 0, err
 	}
-	defer client.Close()
-	// try to find the current node by its id/name
-	for _, node := range client.Cluster().GetNodes() {
-		// node.GetName returns an upper-case string, so we must ignore case
-		if strings.EqualFold(node.GetName(), pod.Annotations[nodeIdAnnotation]) {
-			return node.MigrationInProgress()
-		}
+	size, ok := asutils.ParseStatistics(res["statistics"])["cluster_size"]
+	if !ok {
+		return 0, fmt.Errorf("cluster_size is not present")
 	}
-	return false, fmt.Errorf("failed to find node %s in the cluster", pod.Annotations[nodeIdAnnotation])
+	return strconv.Atoi(size)
 }
 
-func waitForMigrationsToFinishOnPod(pod *v1.Pod) error {
-	client, err := as.NewClient(pod.Status.PodIP, ServicePort)
+func getAerospikeServerVersionFromPod(ctx context.Context, pod *v1.Pod) (string, error) {
+	res, err := runInfoCommandOnPod(ctx, pod, "build")
 	if err != nil {
-		return err
-	}
-	defer client.Close()
-	// try to find the current node by its id/name
-	for _, node := range client.Cluster().GetNodes() {
-		// node.GetName returns an upper-case string, so we must ignore case
-		if strings.EqualFold(node.GetName(), pod.Annotations[nodeIdAnnotation]) {
-			return node.WaitUntillMigrationIsFinished(waitMigrationsTimeout)
-		}
+		return "", err
+	}
+	version, ok := res["build"]
+	if !ok {
+		return "", fmt.Errorf("failed to get aerospike version from pod %v", meta.Key(pod))
 	}
-	return fmt.Errorf("failed to find node %s in the cluster", pod.Annotations[nodeIdAnnotation])
+
+	return version, nil
+}
+
+// supportsQuiesce returns whether the version of aerospike running on the
+// specified pod supports quiescing the node.
+func supportsQuiesce(ctx context.Context, pod *v1.Pod) (bool, error) {
+	return isVersionAtLeast(ctx, pod, quiesceMinVersion)
 }
 
-func runInfoCommandOnPod(pod *v1.Pod, command string) (map[string]string, error) {
-	conn, err := as.NewConnection(&as.ClientPolicy{Timeout: aerospikeClientTimeout}, &as.Host{Name: pod.Status.PodIP, Port: ServicePort})
+// supportsClusterStable returns whether the version of aerospike running on
+// the specified pod supports the cluster-stable info command.
+func supportsClusterStable(ctx context.Context, pod *v1.Pod) (bool, error) {
+	return isVersionAtLeast(ctx, pod, clusterStableMinVersion)
+}
+
+// isVersionAtLeast returns whether the version of aerospike running on the
+// specified pod is the specified version or a later one.
+func isVersionAtLeast(ctx context.Context, pod *v1.Pod, min versioning.Version) (bool, error) {
+	build, err := getAerospikeServerVersionFromPod(ctx, pod)
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	version, err := versioning.NewVersionFromString(build)
+	if err != nil {
+		return false, err
 	}
-	defer conn.Close()
-	return as.RequestInfo(conn, command)
+	return version.IsAtLeast(min), nil
 }
 
-func getAerospikeServerVersionFromPod(pod *v1.Pod) (string, error) {
-	res, err := runInfoCommandOnPod(pod, "build")
+// getStableClusterKey returns the cluster key reported by the aerospike node
+// running on the specified pod if the node sees a cluster of one of the
+// specified sizes with no migrations in progress, or an empty string
+// otherwise. the cluster-stable info command is used where supported, and the
+// cluster size and migrations reported by the statistics info command are
+// checked on older versions of aerospike.
+func getStableClusterKey(ctx context.Context, pod *v1.Pod, clusterSizes []int) (string, error) {
+	supported, err := supportsClusterStable(ctx, pod)
 	if err != nil {
 		return "", err
 	}
-	version, ok := res["build"]
-	if !ok {
-		return "", fmt.Errorf("failed to get aerospike version from pod %v", meta.Key(pod))
+	if supported {
+		commands := make([]string, 0, len(clusterSizes))
+		for _, size := range clusterSizes {
+			commands = append(commands, fmt.Sprintf("cluster-stable:size=%d;ignore-migrations=false", size))
+		}
+		res, err := runInfoCommandOnPod(ctx, pod, commands...)
+		if err != nil {
+			return "", err
+		}
+		// the command returns an error unless the cluster is stable
+		for _, cmd := range commands {
+			if clusterKey := strings.TrimSpace(res[cmd]); clusterKey != "" && !strings.HasPrefix(clusterKey, "ERROR") {
+				return clusterKey, nil
+			}
+		}
+		return "", nil
+	}
+	res, err := runInfoCommandOnPod(ctx, pod, "statistics")
+	if err != nil {
+		return "", err
+	}
+	stats := asutils.ParseStatistics(res["statistics"])
+	if migrationsRemaining(stats) > 0 {
+		return "", nil
 	}
+	for _, size := range clusterSizes {
+		if stats["cluster_size"] == strconv.Itoa(size) {
+			return stats["cluster_key"], nil
+		}
+	}
+	return "", nil
+}
 
-	return version, nil
+// getNamespaceStatistics returns the statistics of every namespace known to
+// the aerospike node running on the specified pod, indexed by namespace name.
+func getNamespaceStatistics(ctx context.Context, pod *v1.Pod) (map[string]map[string]string, error) {
+	res, err := runInfoCommandOnPod(ctx, pod, "namespaces")
+	if err != nil {
+		return nil, err
+	}
+	var commands []string
+	for _, name := range strings.Split(res["namespaces"], ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			commands = append(commands, fmt.Sprintf("namespace/%s", name))
+		}
+	}
+	if len(commands) == 0 {
+		return nil, nil
+	}
+	if res, err = runInfoCommandOnPod(ctx, pod, commands...); err != nil {
+		return nil, err
+	}
+	stats := make(map[string]map[string]string, len(commands))
+	for _, command := range commands {
+		stats[strings.TrimPrefix(command, "namespace/")] = asutils.ParseStatistics(res[command])
+	}
+	return stats, nil
 }
 
-func tipClearHostname(pod *v1.Pod, address string) error {
-	_, err := runInfoCommandOnPod(pod, fmt.Sprintf("tip-clear:host-port-list=%s:%d", address, HeartbeatPort))
+func tipClearHostname(ctx context.Context, pod *v1.Pod, address string) error {
+	_, err := runInfoCommandOnPod(ctx, pod, fmt.Sprintf("tip-clear:host-port-list=%s:%d", address, HeartbeatPort))
 	return err
 }
 
-func alumniReset(pod *v1.Pod) error {
-	_, err := runInfoCommandOnPod(pod, "services-alumni-reset")
+func alumniReset(ctx context.Context, pod *v1.Pod) error {
+	_, err := runInfoCommandOnPod(ctx, pod, "services-alumni-reset")
 	return err
 }
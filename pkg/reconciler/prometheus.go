@@ -0,0 +1,287 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/crd"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+const (
+	// prometheusOperatorGroupVersion is the group/version of the resources
+	// defined by the prometheus operator
+	prometheusOperatorGroupVersion = "monitoring.coreos.com/v1"
+	// prometheusOperatorCheckPeriod is how long the result of checking whether
+	// the prometheus operator crds are installed is cached for
+	prometheusOperatorCheckPeriod = 5 * time.Minute
+)
+
+var (
+	podMonitorsResource = schema.GroupVersionResource{
+		Group:    "monitoring.coreos.com",
+		Version:  "v1",
+		Resource: "podmonitors",
+	}
+	prometheusRulesResource = schema.GroupVersionResource{
+		Group:    "monitoring.coreos.com",
+		Version:  "v1",
+		Resource: "prometheusrules",
+	}
+)
+
+// ensurePrometheusOperatorObjects creates or updates the podmonitor and the
+// prometheusrule for the specified cluster if requested, and deletes them
+// otherwise. nothing is done if the prometheus operator crds are not installed.
+func (r *AerospikeClusterReconciler) ensurePrometheusOperatorObjects(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	var spec *aerospikev1alpha2.PrometheusOperatorSpec
+	if isMonitoringEnabled(aerospikeCluster) && aerospikeCluster.Spec.Monitoring != nil {
+		spec = aerospikeCluster.Spec.Monitoring.PrometheusOperator
+	}
+	enabled := spec != nil && spec.Enabled
+
+	installed, err := r.isPrometheusOperatorInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		if enabled {
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			}).Warn("the prometheus operator crds are not installed, skipping the creation of the podmonitor")
+		}
+		return nil
+	}
+
+	if !enabled {
+		if err := r.deletePrometheusOperatorObject(ctx, aerospikeCluster, podMonitorsResource); err != nil {
+			return err
+		}
+		return r.deletePrometheusOperatorObject(ctx, aerospikeCluster, prometheusRulesResource)
+	}
+	if err := r.applyPrometheusOperatorObject(ctx, aerospikeCluster, podMonitorsResource, buildPodMonitor(aerospikeCluster, spec)); err != nil {
+		return err
+	}
+	if spec.Alerts != nil && !*spec.Alerts {
+		return r.deletePrometheusOperatorObject(ctx, aerospikeCluster, prometheusRulesResource)
+	}
+	return r.applyPrometheusOperatorObject(ctx, aerospikeCluster, prometheusRulesResource, buildPrometheusRule(aerospikeCluster, spec))
+}
+
+// isPrometheusOperatorInstalled returns whether the podmonitor and
+// prometheusrule crds are installed in the kubernetes cluster. the result is
+// cached for prometheusOperatorCheckPeriod so that the discovery api is not
+// queried on every reconcile.
+func (r *AerospikeClusterReconciler) isPrometheusOperatorInstalled() (bool, error) {
+	r.prometheusOperatorMutex.Lock()
+	defer r.prometheusOperatorMutex.Unlock()
+	if !r.prometheusOperatorCheckedAt.IsZero() && time.Since(r.prometheusOperatorCheckedAt) < prometheusOperatorCheckPeriod {
+		return r.prometheusOperatorInstalled, nil
+	}
+	installed := false
+	resources, err := r.kubeclientset.Discovery().ServerResourcesForGroupVersion(prometheusOperatorGroupVersion)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil {
+		found := 0
+		for _, resource := range resources.APIResources {
+			if resource.Name == podMonitorsResource.Resource || resource.Name == prometheusRulesResource.Resource {
+				found++
+			}
+		}
+		installed = found == 2
+	}
+	r.prometheusOperatorInstalled = installed
+	r.prometheusOperatorCheckedAt = time.Now()
+	return installed, nil
+}
+
+// applyPrometheusOperatorObject creates the specified object, or updates it if
+// it already exists and its labels or spec differ from the desired ones.
+func (r *AerospikeClusterReconciler) applyPrometheusOperatorObject(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, resource schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	client := r.dynamicclientset.Resource(resource).Namespace(obj.GetNamespace())
+	current, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if _, err := client.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Debugf("%s created", obj.GetKind())
+		return nil
+	}
+	// never overwrite an object created by someone else
+	if !metav1.IsControlledBy(current, aerospikeCluster) {
+		return fmt.Errorf("%s %s already exists and is not owned by aerospikecluster %s", obj.GetKind(), meta.Key(current), meta.Key(aerospikeCluster))
+	}
+	if reflect.DeepEqual(current.GetLabels(), obj.GetLabels()) && reflect.DeepEqual(current.Object["spec"], obj.Object["spec"]) {
+		return nil
+	}
+	obj.SetResourceVersion(current.GetResourceVersion())
+	if _, err := client.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debugf("%s updated", obj.GetKind())
+	return nil
+}
+
+// deletePrometheusOperatorObject deletes the object of the specified resource
+// created for the specified cluster, if it exists. objects with the same name
+// which are not owned by the cluster are left alone.
+func (r *AerospikeClusterReconciler) deletePrometheusOperatorObject(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, resource schema.GroupVersionResource) error {
+	client := r.dynamicclientset.Resource(resource).Namespace(aerospikeCluster.Namespace)
+	current, err := client.Get(ctx, aerospikeCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(current, aerospikeCluster) {
+		return nil
+	}
+	// make sure we delete the object we have just looked at
+	err = client.Delete(ctx, current.GetName(), metav1.DeleteOptions{
+		Preconditions: metav1.NewUIDPreconditions(string(current.GetUID())),
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// newPrometheusOperatorObject returns an object of the specified kind owned by
+// the specified cluster.
+func newPrometheusOperatorObject(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, spec *aerospikev1alpha2.PrometheusOperatorSpec, kind string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(prometheusOperatorGroupVersion)
+	obj.SetKind(kind)
+	obj.SetName(aerospikeCluster.Name)
+	obj.SetNamespace(aerospikeCluster.Namespace)
+	// labels managed by aerospike-operator take precedence
+	labels := make(map[string]string, len(spec.Labels)+2)
+	for key, value := range spec.Labels {
+		labels[key] = value
+	}
+	labels[selectors.LabelAppKey] = selectors.LabelAppVal
+	labels[selectors.LabelClusterKey] = aerospikeCluster.Name
+	obj.SetLabels(labels)
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		*metav1.NewControllerRef(aerospikeCluster, aerospikev1alpha2.SchemeGroupVersion.WithKind(crd.AerospikeClusterKind)),
+	})
+	return obj
+}
+
+// buildPodMonitor returns the podmonitor that makes prometheus scrape the
+// metrics exporter of every pod in the specified cluster.
+func buildPodMonitor(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, spec *aerospikev1alpha2.PrometheusOperatorSpec) *unstructured.Unstructured {
+	obj := newPrometheusOperatorObject(aerospikeCluster, spec, "PodMonitor")
+	endpoint := map[string]interface{}{
+		"port": aspromContainerPortName,
+		"path": "/metrics",
+	}
+	if spec.Interval != "" {
+		endpoint["interval"] = spec.Interval
+	}
+	obj.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				selectors.LabelAppKey:     selectors.LabelAppVal,
+				selectors.LabelClusterKey: aerospikeCluster.Name,
+			},
+		},
+		"podMetricsEndpoints": []interface{}{
+			endpoint,
+		},
+	}
+	return obj
+}
+
+// buildPrometheusRule returns the prometheusrule holding the default alerts for
+// the specified cluster.
+func buildPrometheusRule(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, spec *aerospikev1alpha2.PrometheusOperatorSpec) *unstructured.Unstructured {
+	obj := newPrometheusOperatorObject(aerospikeCluster, spec, "PrometheusRule")
+	// the prometheus operator sets the job label of the metrics scraped using
+	// a podmonitor to "<namespace>/<name>"
+	selector := fmt.Sprintf(`job="%s/%s"`, aerospikeCluster.Namespace, aerospikeCluster.Name)
+	cluster := meta.Key(aerospikeCluster)
+	obj.Object["spec"] = map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name": fmt.Sprintf("aerospike-%s", aerospikeCluster.Name),
+				"rules": []interface{}{
+					newAlertingRule(
+						"AerospikeNodeDown",
+						fmt.Sprintf("up{%s} == 0 or aerospike_node_up{%s} == 0", selector, selector),
+						"5m",
+						"critical",
+						fmt.Sprintf("Aerospike node {{ $labels.pod }} of cluster %s is down.", cluster),
+					),
+					newAlertingRule(
+						"AerospikeStopWrites",
+						fmt.Sprintf("aerospike_ns_stop_writes{%s} == 1", selector),
+						"1m",
+						"critical",
+						fmt.Sprintf("Aerospike node {{ $labels.pod }} of cluster %s has stopped accepting writes.", cluster),
+					),
+					newAlertingRule(
+						"AerospikeMigrationsStuck",
+						fmt.Sprintf("(aerospike_ns_migrate_tx_partitions_remaining{%s} + aerospike_ns_migrate_rx_partitions_remaining{%s} > 0) and (delta(aerospike_ns_migrate_tx_partitions_remaining{%s}[30m]) + delta(aerospike_ns_migrate_rx_partitions_remaining{%s}[30m]) >= 0)", selector, selector, selector, selector),
+						"30m",
+						"warning",
+						fmt.Sprintf("Migrations on Aerospike node {{ $labels.pod }} of cluster %s have not progressed for 30 minutes.", cluster),
+					),
+				},
+			},
+		},
+	}
+	return obj
+}
+
+// newAlertingRule returns an alerting rule with the specified properties.
+func newAlertingRule(name, expr, duration, severity, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"alert": name,
+		"expr":  expr,
+		"for":   duration,
+		"labels": map[string]interface{}{
+			"severity": severity,
+		},
+		"annotations": map[string]interface{}{
+			"description": description,
+		},
+	}
+}
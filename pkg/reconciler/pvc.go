@@ -33,9 +33,11 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
 )
@@ -61,7 +63,7 @@ func (pvcs fromMostRecent) Less(i, j int) bool {
 	return pvcs[j].CreationTimestamp.Before(&pvcs[i].CreationTimestamp)
 }
 
-func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod) (*v1.PersistentVolumeClaim, error) {
+func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (*v1.PersistentVolumeClaim, error) {
 	// get all the pvcs owned by the aerospikecluster
 	pvcs, err := r.pvcsLister.PersistentVolumeClaims(aerospikeCluster.Namespace).List(selectors.ResourcesByClusterName(aerospikeCluster.Name))
 	if err != nil {
@@ -71,7 +73,7 @@ func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *
 		return nil, nil
 	}
 
-	// filter the ones associated with the pod
+	// filter the ones associated with the pod and namespace
 	var podPVCs []*v1.PersistentVolumeClaim
 	for _, pvc := range pvcs {
 		// skip pvc if it does not belong to the right pod
@@ -79,6 +81,19 @@ func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *
 		if !ok || podName != pod.Name {
 			continue
 		}
+		// skip pvc if it does not belong to the right namespace
+		if pvc.Labels[selectors.LabelNamespaceKey] != namespace.Name {
+			continue
+		}
+		// skip pvc if the pod it was mounted on has been replaced
+		if _, ok := pvc.Annotations[ReplacedOnAnnotation]; ok {
+			continue
+		}
+		// skip pvc if it does not match the requested storage type and
+		// storage class (i.e. the namespace's storage is being migrated)
+		if !persistentVolumeClaimMatchesStorageSpec(pvc, namespace) {
+			continue
+		}
 		// retrieve the timestamp of when the pvc was last unmounted.
 		// if not available, skip this pvc.
 		lastUnmountedString, ok := pvc.Annotations[LastUnmountedOnAnnotation]
@@ -119,7 +134,7 @@ func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *
 	return podPVCs[0], nil
 }
 
-func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (*v1.PersistentVolumeClaim, error) {
+func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (*v1.PersistentVolumeClaim, error) {
 	storageSize, err := resource.ParseQuantity(namespace.Storage.Size)
 	if err != nil {
 		return nil, err
@@ -153,8 +168,9 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 				},
 			},
 			Annotations: map[string]string{
-				PodAnnotation:    pod.Name,
-				PVCTTLAnnotation: persistentVolumeClaimTTL,
+				PodAnnotation:                   pod.Name,
+				PVCTTLAnnotation:                persistentVolumeClaimTTL,
+				requestedStorageClassAnnotation: getRequestedStorageClassName(namespace),
 			},
 		},
 		Spec: v1.PersistentVolumeClaimSpec{
@@ -174,7 +190,7 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 		claim.Spec.StorageClassName = namespace.Storage.StorageClassName
 	}
 
-	pvc, err := r.kubeclientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Create(context.TODO(), claim, metav1.CreateOptions{})
+	pvc, err := r.kubeclientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Create(ctx, claim, metav1.CreateOptions{})
 	if err != nil {
 		log.WithFields(log.Fields{
 			logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
@@ -191,22 +207,238 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 	return pvc, err
 }
 
+// markPersistentVolumeClaimsReplaced marks the pvcs associated with the pod
+// with the specified index as replaced, so that they are not reused by the pod
+// that replaces it and are eventually deleted by the garbage collector.
+func (r *AerospikeClusterReconciler) markPersistentVolumeClaimsReplaced(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int) error {
+	pvcs, err := r.pvcsLister.PersistentVolumeClaims(aerospikeCluster.Namespace).List(selectors.ResourcesByClusterName(aerospikeCluster.Name))
+	if err != nil {
+		return err
+	}
+	podName := fmt.Sprintf("%s-%d", aerospikeCluster.Name, index)
+	for _, pvc := range pvcs {
+		if pvc.Annotations[PodAnnotation] != podName {
+			continue
+		}
+		if _, ok := pvc.Annotations[ReplacedOnAnnotation]; ok {
+			continue
+		}
+		oldPVC := pvc.DeepCopy()
+		newPVC := pvc.DeepCopy()
+		setPVCAnnotation(newPVC, ReplacedOnAnnotation, time.Now().Format(time.RFC3339))
+		// pvcs which were never unmounted would otherwise never expire
+		if _, ok := newPVC.Annotations[LastUnmountedOnAnnotation]; !ok {
+			setPVCAnnotation(newPVC, LastUnmountedOnAnnotation, time.Now().Format(time.RFC3339))
+		}
+		if err := r.patchPVC(ctx, oldPVC, newPVC); err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+			logfields.PodIndex:              index,
+			logfields.PersistentVolumeClaim: pvc.Name,
+		}).Debug("persistentvolumeclaim marked as replaced")
+	}
+	return nil
+}
+
+// ensurePersistentVolumeClaimsSize makes sure that the persistent volume
+// claims mounted by the specified pod are at least as large as the storage
+// size requested for the corresponding namespaces, expanding them if needed.
+func (r *AerospikeClusterReconciler) ensurePersistentVolumeClaimsSize(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod) error {
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		claimName := getPodPersistentVolumeClaimName(pod, &namespace)
+		if claimName == "" {
+			// the namespace was added after the pod was created, and the pod
+			// will be restarted with a new pvc
+			continue
+		}
+		pvc, err := r.pvcsLister.PersistentVolumeClaims(pod.Namespace).Get(claimName)
+		if err != nil {
+			return err
+		}
+		if err := r.expandPersistentVolumeClaim(ctx, aerospikeCluster, pvc, &namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandPersistentVolumeClaim requests the expansion of the specified pvc if it
+// is smaller than the storage size requested for the namespace. it returns a
+// RequeueError until the underlying volume has been resized.
+func (r *AerospikeClusterReconciler) expandPersistentVolumeClaim(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pvc *v1.PersistentVolumeClaim, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) error {
+	desiredSize, err := resource.ParseQuantity(namespace.Storage.Size)
+	if err != nil {
+		return err
+	}
+	// request the expansion of the pvc if needed
+	currentSize := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if currentSize.Cmp(desiredSize) < 0 {
+		newPVC := pvc.DeepCopy()
+		if newPVC.Spec.Resources.Requests == nil {
+			newPVC.Spec.Resources.Requests = make(v1.ResourceList)
+		}
+		newPVC.Spec.Resources.Requests[v1.ResourceStorage] = desiredSize
+		setPVCAnnotation(newPVC, expansionRequestedOnAnnotation, time.Now().Format(time.RFC3339))
+		if err := r.patchPVC(ctx, pvc, newPVC); err != nil {
+			return err
+		}
+		r.recorder.Eventf(aerospikeCluster, v1.EventTypeNormal, events.ReasonVolumeExpansionStarted,
+			"expanding persistentvolumeclaim %s from %s to %s", meta.Key(pvc), currentSize.String(), desiredSize.String())
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+			logfields.PersistentVolumeClaim: pvc.Name,
+		}).Infof("expanding persistentvolumeclaim from %s to %s", currentSize.String(), desiredSize.String())
+		return errors.NewRequeueError(pvcResizePollPeriod, "waiting for persistentvolumeclaim %s to be expanded", meta.Key(pvc))
+	}
+	requestedOnString, expanding := pvc.Annotations[expansionRequestedOnAnnotation]
+	// if the volume has already been resized we're good to go
+	if isPersistentVolumeClaimResized(pvc, desiredSize) {
+		if !expanding {
+			return nil
+		}
+		newPVC := pvc.DeepCopy()
+		removePVCAnnotation(newPVC, expansionRequestedOnAnnotation)
+		if err := r.patchPVC(ctx, pvc, newPVC); err != nil {
+			return err
+		}
+		r.recorder.Eventf(aerospikeCluster, v1.EventTypeNormal, events.ReasonVolumeExpansionFinished,
+			"persistentvolumeclaim %s expanded to %s", meta.Key(pvc), desiredSize.String())
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+			logfields.PersistentVolumeClaim: pvc.Name,
+		}).Infof("persistentvolumeclaim expanded to %s", desiredSize.String())
+		return nil
+	}
+	// give up waiting for the volume to be resized after a while
+	if requestedOn, err := time.Parse(time.RFC3339, requestedOnString); err == nil && time.Since(requestedOn) > waitPVCResizeTimeout {
+		r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonVolumeExpansionFailed,
+			"timed out waiting for persistentvolumeclaim %s to be expanded", meta.Key(pvc))
+		return fmt.Errorf("timed out waiting for persistentvolumeclaim %s to be expanded", meta.Key(pvc))
+	}
+	return errors.NewRequeueError(pvcResizePollPeriod, "waiting for persistentvolumeclaim %s to be expanded", meta.Key(pvc))
+}
+
+// podNeedsStorageMigration returns whether the storage type or storage class
+// of any namespace has changed since the last successful reconcile and any of
+// the persistent volume claims mounted by the specified pod does not match the
+// new storage type or storage class.
+func (r *AerospikeClusterReconciler) podNeedsStorageMigration(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod) (bool, error) {
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		if !isStorageMigrationRequested(aerospikeCluster, &namespace) {
+			continue
+		}
+		claimName := getPodPersistentVolumeClaimName(pod, &namespace)
+		if claimName == "" {
+			continue
+		}
+		pvc, err := r.pvcsLister.PersistentVolumeClaims(pod.Namespace).Get(claimName)
+		if err != nil {
+			return false, err
+		}
+		if !persistentVolumeClaimMatchesStorageSpec(pvc, &namespace) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isStorageMigrationRequested returns whether the storage type or storage class
+// of the specified namespace differs from the one in the last successfully
+// reconciled spec. namespaces being added are not taken into account.
+func isStorageMigrationRequested(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) bool {
+	for _, old := range aerospikeCluster.Status.Namespaces {
+		if old.Name == namespace.Name {
+			return old.Storage.Type != namespace.Storage.Type || getRequestedStorageClassName(&old) != getRequestedStorageClassName(namespace)
+		}
+	}
+	return false
+}
+
+// persistentVolumeClaimMatchesStorageSpec returns whether the specified pvc
+// matches the storage type and storage class requested for the namespace.
+func persistentVolumeClaimMatchesStorageSpec(pvc *v1.PersistentVolumeClaim, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) bool {
+	// pvcs without a volume mode use a filesystem
+	volumeMode := v1.PersistentVolumeFilesystem
+	if pvc.Spec.VolumeMode != nil {
+		volumeMode = *pvc.Spec.VolumeMode
+	}
+	if volumeMode != volumeModeMap[namespace.Storage.Type] {
+		return false
+	}
+	storageClassName := getRequestedStorageClassName(namespace)
+	// compare against the storage class requested when the pvc was created
+	// rather than the one it was given, as the default storage class may
+	// change over time
+	if requested, ok := pvc.Annotations[requestedStorageClassAnnotation]; ok {
+		return requested == storageClassName
+	}
+	// pvcs created by previous versions of aerospike-operator do not hold the
+	// requested storage class, in which case any pvc will do if no storage
+	// class has been requested
+	if storageClassName == "" {
+		return true
+	}
+	return pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == storageClassName
+}
+
+// getRequestedStorageClassName returns the name of the storage class requested
+// for the specified namespace, or an empty string if the default storage class
+// is to be used.
+func getRequestedStorageClassName(namespace *aerospikev1alpha2.AerospikeNamespaceSpec) string {
+	if namespace.Storage.StorageClassName == nil {
+		return ""
+	}
+	return *namespace.Storage.StorageClassName
+}
+
+// isPersistentVolumeClaimResized returns whether the volume bound to the
+// specified pvc has been resized to (at least) the specified size. a pending
+// filesystem resize is considered as done, since it is performed by the kubelet
+// when the pvc is (re-)mounted.
+func isPersistentVolumeClaimResized(pvc *v1.PersistentVolumeClaim, size resource.Quantity) bool {
+	capacity := pvc.Status.Capacity[v1.ResourceStorage]
+	if capacity.Cmp(size) >= 0 {
+		return true
+	}
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Type == v1.PersistentVolumeClaimFileSystemResizePending && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// getPodPersistentVolumeClaimName returns the name of the pvc mounted by the
+// specified pod for the specified namespace, or an empty string if there is
+// none.
+func getPodPersistentVolumeClaimName(pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) string {
+	volumeName := fmt.Sprintf("%s-%s", namespaceVolumePrefix, namespace.Name)
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == volumeName && volume.PersistentVolumeClaim != nil {
+			return volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+	return ""
+}
+
 // getIndexBasedDevicePath returns the device path for the namespace
 // with the specified index (e.g. 0 --> /dev/xvda, 1 --> /dev/xvdb, ...).
 func getIndexBasedDevicePath(index int) string {
 	return fmt.Sprintf("%s%s", defaultDevicePathPrefix, fmt.Sprint('a'+index))
 }
 
-func (r *AerospikeClusterReconciler) signalMounted(pvc *v1.PersistentVolumeClaim) error {
+func (r *AerospikeClusterReconciler) signalMounted(ctx context.Context, pvc *v1.PersistentVolumeClaim) error {
 	oldPVC := pvc.DeepCopy()
 	removePVCAnnotation(pvc, LastUnmountedOnAnnotation)
-	return r.patchPVC(oldPVC, pvc)
+	return r.patchPVC(ctx, oldPVC, pvc)
 }
 
-func (r *AerospikeClusterReconciler) signalUnmounted(pvc *v1.PersistentVolumeClaim) error {
+func (r *AerospikeClusterReconciler) signalUnmounted(ctx context.Context, pvc *v1.PersistentVolumeClaim) error {
 	oldPVC := pvc.DeepCopy()
 	setPVCAnnotation(pvc, LastUnmountedOnAnnotation, time.Now().Format(time.RFC3339))
-	return r.patchPVC(oldPVC, pvc)
+	return r.patchPVC(ctx, oldPVC, pvc)
 }
 
 // setPVCAnnotation sets an annotation with the specified key and value in the
@@ -225,7 +457,7 @@ func removePVCAnnotation(pvc *v1.PersistentVolumeClaim, key string) {
 }
 
 // patchCluster updates the status field of the aerospikeCluster
-func (r *AerospikeClusterReconciler) patchPVC(old, new *v1.PersistentVolumeClaim) error {
+func (r *AerospikeClusterReconciler) patchPVC(ctx context.Context, old, new *v1.PersistentVolumeClaim) error {
 	oldBytes, err := json.Marshal(old)
 	if err != nil {
 		return err
@@ -238,7 +470,7 @@ func (r *AerospikeClusterReconciler) patchPVC(old, new *v1.PersistentVolumeClaim
 	if err != nil {
 		return err
 	}
-	_, err = r.kubeclientset.CoreV1().PersistentVolumeClaims(old.Namespace).Patch(context.TODO(), old.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	_, err = r.kubeclientset.CoreV1().PersistentVolumeClaims(old.Namespace).Patch(ctx, old.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,142 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+)
+
+// getReplicationFactorChanges returns a description of every change to the
+// replication factor of the namespaces in the specified cluster since the last
+// successful reconcile.
+func getReplicationFactorChanges(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []string {
+	current := make(map[string]*int32, len(aerospikeCluster.Status.Namespaces))
+	for _, namespace := range aerospikeCluster.Status.Namespaces {
+		current[namespace.Name] = namespace.ReplicationFactor
+	}
+	var res []string
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		// namespaces being added are not taken into account
+		old, ok := current[namespace.Name]
+		if !ok {
+			continue
+		}
+		if oldValue, newValue := replicationFactorString(old), replicationFactorString(namespace.ReplicationFactor); oldValue != newValue {
+			res = append(res, fmt.Sprintf("%s from %s to %s", namespace.Name, oldValue, newValue))
+		}
+	}
+	return res
+}
+
+// replicationFactorString returns a string representation of the specified
+// replication factor.
+func replicationFactorString(replicationFactor *int32) string {
+	if replicationFactor == nil {
+		return "default"
+	}
+	return strconv.Itoa(int(*replicationFactor))
+}
+
+// isReplicationFactorChangeInProgress returns whether the replication factor of
+// any namespace in the specified cluster is being changed.
+func isReplicationFactorChangeInProgress(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) bool {
+	_, ok := aerospikeCluster.Annotations[ReplicationFactorChangeAnnotationKey]
+	return ok
+}
+
+func (r *AerospikeClusterReconciler) signalReplicationFactorChangeStarted(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, changes []string) (*aerospikev1alpha2.AerospikeCluster, error) {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	message := fmt.Sprintf("changing the replication factor of %s", strings.Join(changes, ", "))
+	setCondition(aerospikeCluster, replicationFactorChangeConditions, common.ConditionReplicationFactorChangeStarted, events.ReasonReplicationFactorChangeStarted, message)
+	setAerospikeClusterAnnotation(aerospikeCluster, ReplicationFactorChangeAnnotationKey, ReplicationFactorChangeStartedAnnotationValue)
+
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
+		return nil, err
+	}
+
+	r.recorder.Event(aerospikeCluster, v1.EventTypeNormal, events.ReasonReplicationFactorChangeStarted, message)
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug(message)
+
+	return aerospikeCluster, nil
+}
+
+// signalReplicationFactorChangeProgress records the number of pods that have
+// already been restarted with the new replication factor in the message of the
+// ReplicationFactorChangeStarted condition.
+func (r *AerospikeClusterReconciler) signalReplicationFactorChangeProgress(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, done, total int) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	if condition := apimeta.FindStatusCondition(aerospikeCluster.Status.Conditions, common.ConditionReplicationFactorChangeStarted); condition != nil {
+		// strip any progress information from a previous update
+		message := strings.SplitN(condition.Message, " (", 2)[0]
+		setCondition(aerospikeCluster, replicationFactorChangeConditions, condition.Type, condition.Reason,
+			fmt.Sprintf("%s (%d/%d pods restarted)", message, done, total))
+	}
+
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debugf("replication factor change: %d/%d pods restarted", done, total)
+
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) signalReplicationFactorChangeFinished(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, replicationFactorChangeConditions, common.ConditionReplicationFactorChangeFinished, events.ReasonReplicationFactorChangeFinished, "replication factor change finished")
+	removeAerospikeClusterAnnotation(aerospikeCluster, ReplicationFactorChangeAnnotationKey)
+
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
+		return nil, err
+	}
+
+	r.recorder.Event(aerospikeCluster, v1.EventTypeNormal, events.ReasonReplicationFactorChangeFinished,
+		"replication factor change finished")
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug("replication factor change finished")
+
+	return aerospikeCluster, nil
+}
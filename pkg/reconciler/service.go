@@ -33,7 +33,7 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 )
 
-func (r *AerospikeClusterReconciler) ensureService(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+func (r *AerospikeClusterReconciler) ensureService(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	service := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: aerospikeCluster.Name,
@@ -69,17 +69,20 @@ func (r *AerospikeClusterReconciler) ensureService(aerospikeCluster *aerospikev1
 					Port:       HeartbeatPort,
 					TargetPort: intstr.IntOrString{StrVal: heartbeatPortName},
 				},
-				{
-					Name:       aspromPortName,
-					Port:       aspromPort,
-					TargetPort: intstr.IntOrString{StrVal: aspromPortName},
-				},
 			},
 			ClusterIP: v1.ClusterIPNone,
 		},
 	}
+	// expose the metrics exporter's port
+	if isMonitoringEnabled(aerospikeCluster) {
+		service.Spec.Ports = append(service.Spec.Ports, v1.ServicePort{
+			Name:       aspromPortName,
+			Port:       getMonitoringPort(aerospikeCluster),
+			TargetPort: intstr.IntOrString{StrVal: aspromPortName},
+		})
+	}
 
-	if _, err := r.kubeclientset.CoreV1().Services(aerospikeCluster.Namespace).Create(context.TODO(), service, metav1.CreateOptions{}); err != nil {
+	if _, err := r.kubeclientset.CoreV1().Services(aerospikeCluster.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return err
 		}
@@ -87,7 +90,7 @@ func (r *AerospikeClusterReconciler) ensureService(aerospikeCluster *aerospikev1
 			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
 			logfields.Service:          service.Name,
 		}).Debug("service already exists")
-		return nil
+		return r.maybeUpdateServicePorts(ctx, aerospikeCluster, service)
 	}
 
 	log.WithFields(log.Fields{
@@ -96,3 +99,40 @@ func (r *AerospikeClusterReconciler) ensureService(aerospikeCluster *aerospikev1
 	}).Debug("service created")
 	return nil
 }
+
+// maybeUpdateServicePorts updates the ports of the existing service for the
+// cluster if they differ from the desired ones (e.g. because the port of the
+// metrics exporter has been changed).
+func (r *AerospikeClusterReconciler) maybeUpdateServicePorts(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desired *v1.Service) error {
+	current, err := r.servicesLister.Services(desired.Namespace).Get(desired.Name)
+	if err != nil {
+		return err
+	}
+	if !servicePortsDiffer(current.Spec.Ports, desired.Spec.Ports) {
+		return nil
+	}
+	service := current.DeepCopy()
+	service.Spec.Ports = desired.Spec.Ports
+	if _, err := r.kubeclientset.CoreV1().Services(service.Namespace).Update(ctx, service, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.Service:          service.Name,
+	}).Debug("service ports updated")
+	return nil
+}
+
+// servicePortsDiffer returns whether the names or numbers of the specified
+// service ports differ, disregarding the fields defaulted by kubernetes.
+func servicePortsDiffer(current, desired []v1.ServicePort) bool {
+	if len(current) != len(desired) {
+		return true
+	}
+	for i := range current {
+		if current[i].Name != desired[i].Name || current[i].Port != desired[i].Port {
+			return true
+		}
+	}
+	return false
+}
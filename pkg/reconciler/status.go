@@ -19,17 +19,26 @@ package reconciler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/conditions"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
 )
 
 // updateStatus updates the status of aerospikeCluster to match the spec.
@@ -41,10 +50,178 @@ func (r *AerospikeClusterReconciler) updateStatus(aerospikeCluster *aerospikev1a
 	aerospikeCluster.Status.Namespaces = aerospikeCluster.Spec.Namespaces
 	aerospikeCluster.Status.NodeCount = aerospikeCluster.Spec.NodeCount
 	aerospikeCluster.Status.Version = aerospikeCluster.Spec.Version
+	aerospikeCluster.Status.RestartGeneration = aerospikeCluster.Spec.RestartGeneration
+	aerospikeCluster.Status.ObservedGeneration = aerospikeCluster.Generation
+}
+
+// updateObservedStatus updates the phase of the cluster, the description of
+// the operation in progress and the state of each aerospike node reported in
+// the status of the cluster. it is called after every reconcile, regardless
+// of its outcome, which is given by reconcileErr.
+func (r *AerospikeClusterReconciler) updateObservedStatus(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, reconcileErr error) error {
+	// grab the latest version of the resource, as it may have been updated
+	// while reconciling
+	current, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(aerospikeCluster.Namespace).Get(ctx, aerospikeCluster.Name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pods, err := r.listClusterPods(current)
+	if err != nil {
+		return err
+	}
+
+	new := current.DeepCopy()
+	new.Status.Nodes = nil
+	new.Status.ClusterKey = ""
+	clusterKeys := make(map[string]bool)
+	for _, pod := range pods {
+		node, clusterKey := getNodeStatus(ctx, pod)
+		new.Status.Nodes = append(new.Status.Nodes, node)
+		if node.Ready {
+			clusterKeys[clusterKey] = true
+		}
+	}
+	// only report the cluster key if every ready node agrees on it
+	if len(clusterKeys) == 1 {
+		for clusterKey := range clusterKeys {
+			new.Status.ClusterKey = clusterKey
+		}
+	}
+	new.Status.Phase = computePhase(new)
+	new.Status.Operation = r.describeOperation(new, pods)
+	setObservedConditions(new, aerospikeCluster, r.validate(aerospikeCluster), reconcileErr)
+
+	if reflect.DeepEqual(current.Status, new.Status) {
+		return nil
+	}
+	if _, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(new.Namespace).UpdateStatus(ctx, new, v1.UpdateOptions{}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(new),
+	}).Debug("observed status updated")
+	return nil
+}
+
+// getNodeStatus returns the observed state of the aerospike node running in
+// the specified pod, as well as the cluster key it reports. aerospike is only
+// queried if the pod is running and ready.
+func getNodeStatus(ctx context.Context, pod *corev1.Pod) (aerospikev1alpha2.AerospikeNodeStatus, string) {
+	node := aerospikev1alpha2.AerospikeNodeStatus{
+		PodName: pod.Name,
+		NodeID:  pod.Annotations[nodeIdAnnotation],
+		PodIP:   pod.Status.PodIP,
+		Ready:   isPodRunningAndReady(pod),
+	}
+	if !node.Ready {
+		return node, ""
+	}
+	res, err := runInfoCommandOnPod(ctx, pod, "build", "statistics")
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.Pod: meta.Key(pod),
+		}).Debugf("failed to get the state of the aerospike node: %v", err)
+		return node, ""
+	}
+	stats := asutils.ParseStatistics(res["statistics"])
+	node.Version = res["build"]
+	node.ClusterSize, _ = strconv.Atoi(stats["cluster_size"])
+	node.MigrationsRemaining = migrationsRemaining(stats)
+	return node, stats["cluster_key"]
+}
+
+// computePhase returns the phase the specified cluster is in according to its
+// annotations and to the operation in progress.
+func computePhase(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) common.AerospikeClusterPhase {
+	if status, ok := aerospikeCluster.Annotations[UpgradeStatusAnnotationKey]; ok {
+		if status == UpgradeStatusFailedAnnotationValue {
+			return common.AerospikeClusterPhaseFailed
+		}
+		return common.AerospikeClusterPhaseUpgrading
+	}
+	// the cluster has never been reconciled successfully
+	if aerospikeCluster.Status.Version == "" {
+		return common.AerospikeClusterPhaseCreating
+	}
+	if op := aerospikeCluster.Status.PodOperation; op != nil {
+		switch op.Type {
+		case common.PodOperationTypeCreate, common.PodOperationTypeDelete:
+			return common.AerospikeClusterPhaseScaling
+		case common.PodOperationTypeUpgrade:
+			return common.AerospikeClusterPhaseUpgrading
+		default:
+			return common.AerospikeClusterPhaseRestarting
+		}
+	}
+	return common.AerospikeClusterPhaseRunning
+}
+
+// describeOperation returns a human-readable description of the operation in
+// progress on the specified cluster and of its progress, which is measured by
+// the number of desired pods that need no further changes.
+func (r *AerospikeClusterReconciler) describeOperation(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pods []*corev1.Pod) string {
+	if aerospikeCluster.Annotations[UpgradeStatusAnnotationKey] == UpgradeStatusBackupAnnotationValue {
+		return "backing up namespaces before upgrading"
+	}
+	op := aerospikeCluster.Status.PodOperation
+	if op == nil {
+		return ""
+	}
+	desiredPods := computeDesiredPods(aerospikeCluster, pods)
+	if op.Type == common.PodOperationTypeDelete {
+		return fmt.Sprintf("removing pods (%d left)", len(pods)-len(desiredPods))
+	}
+
+	// the configmap is only required to tell whether pods need to be
+	// restarted
+	configMap, _ := r.configMapsLister.ConfigMaps(aerospikeCluster.Namespace).Get(aerospikeCluster.Name)
+	done := 0
+	for _, pod := range pods {
+		if _, ok := desiredPods[podIndex(pod)]; !ok || podIndex(pod) == op.PodIndex || !isPodRunningAndReady(pod) {
+			continue
+		}
+		switch op.Type {
+		case common.PodOperationTypeUpgrade:
+			for _, node := range aerospikeCluster.Status.Nodes {
+				if node.PodName == pod.Name && node.Version == aerospikeCluster.Spec.Version {
+					done++
+				}
+			}
+		case common.PodOperationTypeRestart:
+			if configMap != nil && !podNeedsRestart(configMap, pod) && !isPodSpecOutdated(aerospikeCluster, pod) && !isPodRestartRequested(aerospikeCluster, pod) {
+				done++
+			}
+		case common.PodOperationTypeReplace:
+			if !isPodReplacementRequested(pod) {
+				done++
+			}
+		case common.PodOperationTypeMigrateStorage:
+			if needsStorageMigration, err := r.podNeedsStorageMigration(aerospikeCluster, pod); err == nil && !needsStorageMigration {
+				done++
+			}
+		default:
+			done++
+		}
+	}
+
+	var verb string
+	switch op.Type {
+	case common.PodOperationTypeCreate:
+		verb = "creating pods"
+	case common.PodOperationTypeUpgrade:
+		verb = "upgrading"
+	case common.PodOperationTypeMigrateStorage:
+		verb = "migrating storage"
+	case common.PodOperationTypeReplace:
+		verb = "replacing"
+	default:
+		verb = "restarting"
+	}
+	return fmt.Sprintf("%s %d/%d", verb, done, len(desiredPods))
 }
 
 // patchCluster updates the aerospikecluster resource.
-func (r *AerospikeClusterReconciler) patchCluster(old, new *aerospikev1alpha2.AerospikeCluster) error {
+func (r *AerospikeClusterReconciler) patchCluster(ctx context.Context, old, new *aerospikev1alpha2.AerospikeCluster) error {
 	// return if there are no changes to patch
 	if reflect.DeepEqual(old, new) {
 		return nil
@@ -63,7 +240,7 @@ func (r *AerospikeClusterReconciler) patchCluster(old, new *aerospikev1alpha2.Ae
 	}
 	// grab the status changes before patching
 	newStatus := new.Status
-	new, err = r.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(old.Namespace).Patch(context.TODO(), old.Name, types.MergePatchType, patchBytes, v1.PatchOptions{})
+	new, err = r.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(old.Namespace).Patch(ctx, old.Name, types.MergePatchType, patchBytes, v1.PatchOptions{})
 	if err != nil {
 		return err
 	}
@@ -74,7 +251,7 @@ func (r *AerospikeClusterReconciler) patchCluster(old, new *aerospikev1alpha2.Ae
 	// update the status subresource
 	if !reflect.DeepEqual(new.Status, newStatus) {
 		new.Status = newStatus
-		new, err = r.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(new.Namespace).UpdateStatus(context.TODO(), new, v1.UpdateOptions{})
+		new, err = r.aerospikeclientset.AerospikeV1alpha2().AerospikeClusters(new.Namespace).UpdateStatus(ctx, new, v1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
@@ -85,10 +262,88 @@ func (r *AerospikeClusterReconciler) patchCluster(old, new *aerospikev1alpha2.Ae
 	return nil
 }
 
-// appendCondition appends the specified condition to the aerospikeCluster
-// object
-func appendCondition(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, condition apiextensions.CustomResourceDefinitionCondition) {
-	aerospikeCluster.Status.Conditions = append(aerospikeCluster.Status.Conditions, condition)
+var (
+	// autoBackupConditions are the conditions reporting the state of the
+	// backup performed before upgrading a cluster.
+	autoBackupConditions = []string{common.ConditionAutoBackupStarted, common.ConditionAutoBackupFinished, common.ConditionAutoBackupFailed}
+	// upgradeConditions are the conditions reporting the state of an upgrade.
+	upgradeConditions = []string{common.ConditionUpgradeStarted, common.ConditionUpgradeFinished, common.ConditionUpgradeFailed}
+	// replicationFactorChangeConditions are the conditions reporting the state
+	// of a change to the replication factor of a namespace.
+	replicationFactorChangeConditions = []string{common.ConditionReplicationFactorChangeStarted, common.ConditionReplicationFactorChangeFinished}
+)
+
+// setCondition sets the condition with the specified type to True in the
+// aerospikeCluster object, and every other condition in group to False.
+func setCondition(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, group []string, conditionType, reason, message string) {
+	conditions.SetExclusive(&aerospikeCluster.Status.Conditions, group, conditionType, aerospikeCluster.Generation, reason, message)
+}
+
+// setObservedConditions sets the Paused, Valid, Ready, Progressing and
+// Degraded conditions of the specified cluster according to its observed state
+// and to the outcome of validating and reconciling the reconciled object.
+func setObservedConditions(aerospikeCluster, reconciled *aerospikev1alpha2.AerospikeCluster, validationErr, reconcileErr error) {
+	generation := reconciled.Generation
+	if reconciled.Spec.Paused {
+		message := "reconciliation is paused"
+		// a quiesced node remains out of service until reconciliation is
+		// resumed, as no info commands changing its state are issued while paused
+		if op := aerospikeCluster.Status.PodOperation; op != nil && op.Step == common.PodOperationStepQuiescing {
+			message = fmt.Sprintf("reconciliation is paused while the aerospike node on pod %s-%d is quiesced", aerospikeCluster.Name, op.PodIndex)
+		}
+		conditions.Set(&aerospikeCluster.Status.Conditions, common.ConditionPaused, v1.ConditionTrue, generation, events.ReasonReconciliationPaused, message)
+	} else if apimeta.FindStatusCondition(aerospikeCluster.Status.Conditions, common.ConditionPaused) != nil {
+		conditions.Set(&aerospikeCluster.Status.Conditions, common.ConditionPaused, v1.ConditionFalse, generation, events.ReasonReconciliationResumed, "reconciliation has been resumed")
+	}
+
+	if validationErr != nil {
+		conditions.Set(&aerospikeCluster.Status.Conditions, common.ConditionValid, v1.ConditionFalse, generation, events.ReasonValidationError, validationErr.Error())
+	} else {
+		conditions.Set(&aerospikeCluster.Status.Conditions, common.ConditionValid, v1.ConditionTrue, generation, events.ReasonValidationSucceeded, "")
+	}
+
+	ready := 0
+	for _, node := range aerospikeCluster.Status.Nodes {
+		if node.Ready {
+			ready++
+		}
+	}
+	status, reason := v1.ConditionFalse, events.ReasonNodesNotReady
+	if ready >= int(reconciled.Spec.NodeCount) {
+		status, reason = v1.ConditionTrue, events.ReasonNodesReady
+	}
+	conditions.Set(&aerospikeCluster.Status.Conditions, common.ConditionReady, status, generation, reason,
+		fmt.Sprintf("%d/%d nodes are ready", ready, reconciled.Spec.NodeCount))
+
+	phase := aerospikeCluster.Status.Phase
+	status, message := v1.ConditionFalse, "no operation in progress"
+	if reconciled.Spec.Paused {
+		message = "reconciliation is paused"
+	} else if phase != common.AerospikeClusterPhaseRunning && phase != common.AerospikeClusterPhaseFailed {
+		status, message = v1.ConditionTrue, aerospikeCluster.Status.Operation
+		if message == "" {
+			message = strings.ToLower(string(phase))
+		}
+	}
+	conditions.Set(&aerospikeCluster.Status.Conditions, common.ConditionProgressing, status, generation, string(phase), message)
+
+	status, reason, message = v1.ConditionFalse, events.ReasonAsExpected, ""
+	if err, ok := errors.AsUnstableClusterError(reconcileErr); ok {
+		status, reason, message = v1.ConditionTrue, events.ReasonClusterNotStable, err.Error()
+	} else if _, ok := errors.AsRequeueError(reconcileErr); reconcileErr != nil && !ok {
+		status, reason, message = v1.ConditionTrue, events.ReasonReconcileFailed, reconcileErr.Error()
+	} else if op := aerospikeCluster.Status.PodOperation; op != nil && op.QuiesceFailures > 0 && (op.Step == common.PodOperationStepWaitingForMigrations || op.Step == common.PodOperationStepQuiescing) {
+		// the operator backs off between attempts at quiescing a node, so
+		// the recorded failures are what tells the cluster is not stable
+		status, reason = v1.ConditionTrue, events.ReasonClusterNotStable
+		message = fmt.Sprintf("the aerospike node on pod %s-%d failed to hand off its master partitions %d time(s)", aerospikeCluster.Name, op.PodIndex, op.QuiesceFailures)
+	} else if phase == common.AerospikeClusterPhaseFailed {
+		status, reason, message = v1.ConditionTrue, events.ReasonClusterUpgradeFailed, "upgrade failed"
+		if c := apimeta.FindStatusCondition(aerospikeCluster.Status.Conditions, common.ConditionUpgradeFailed); c != nil {
+			message = c.Message
+		}
+	}
+	conditions.Set(&aerospikeCluster.Status.Conditions, common.ConditionDegraded, status, generation, reason, message)
 }
 
 // setAerospikeClusterAnnotation sets an annotation with the specified key and value in the
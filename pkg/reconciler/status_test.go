@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+)
+
+func TestDegradedConditionReflectsQuiesceFailures(t *testing.T) {
+	testCases := []struct {
+		name       string
+		op         *aerospikev1alpha2.PodOperation
+		wantStatus v1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "no pod operation",
+			wantStatus: v1.ConditionFalse,
+			wantReason: events.ReasonAsExpected,
+		},
+		{
+			name:       "no quiesce failures",
+			op:         &aerospikev1alpha2.PodOperation{Step: common.PodOperationStepWaitingForMigrations},
+			wantStatus: v1.ConditionFalse,
+			wantReason: events.ReasonAsExpected,
+		},
+		{
+			name:       "backing off after a quiesce failure",
+			op:         &aerospikev1alpha2.PodOperation{Step: common.PodOperationStepWaitingForMigrations, QuiesceFailures: 2},
+			wantStatus: v1.ConditionTrue,
+			wantReason: events.ReasonClusterNotStable,
+		},
+		{
+			name:       "quiescing again after a quiesce failure",
+			op:         &aerospikev1alpha2.PodOperation{Step: common.PodOperationStepQuiescing, QuiesceFailures: 1},
+			wantStatus: v1.ConditionTrue,
+			wantReason: events.ReasonClusterNotStable,
+		},
+		{
+			name:       "deleting the pod after a quiesce failure",
+			op:         &aerospikev1alpha2.PodOperation{Step: common.PodOperationStepDeleting, QuiesceFailures: 1},
+			wantStatus: v1.ConditionFalse,
+			wantReason: events.ReasonAsExpected,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{}
+			aerospikeCluster.Name = "as-cluster-0"
+			aerospikeCluster.Status.Phase = common.AerospikeClusterPhaseRunning
+			aerospikeCluster.Status.PodOperation = tc.op
+			reconcileErr := errors.NewRequeueError(0, "backing off")
+			setObservedConditions(aerospikeCluster, aerospikeCluster.DeepCopy(), nil, reconcileErr)
+			c := apimeta.FindStatusCondition(aerospikeCluster.Status.Conditions, common.ConditionDegraded)
+			if assert.NotNil(t, c) {
+				assert.Equal(t, tc.wantStatus, c.Status)
+				assert.Equal(t, tc.wantReason, c.Reason)
+			}
+		})
+	}
+}
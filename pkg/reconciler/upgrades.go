@@ -17,13 +17,11 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"fmt"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
@@ -33,76 +31,15 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
-func (r *AerospikeClusterReconciler) maybeUpgradePodWithIndex(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *v1.ConfigMap, index int, upgrade *versioning.VersionUpgrade) (*v1.Pod, error) {
-	// check whether a pod with the specified index exists
-	pod, err := r.getPodWithIndex(aerospikeCluster, index)
-	if err != nil {
-		// we've failed to get the pod with the specified index
-		return nil, err
-	}
-	if pod == nil {
-		// no pod with the specified index exists, so we return
-		return nil, nil
-	}
-	// get the version of aerospike server running on the pod
-	version, err := getAerospikeServerVersionFromPod(pod)
-	if err != nil {
-		return nil, err
-	}
-	// skip the upgrade if the pod is already running the target version
-	if version == aerospikeCluster.Spec.Version {
-		return pod, nil
-	}
-
-	log.WithFields(log.Fields{
-		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-	}).Debugf("upgrading pod %s to version %s", meta.Key(pod), aerospikeCluster.Spec.Version)
-	r.recorder.Eventf(aerospikeCluster, v1.EventTypeNormal, events.ReasonNodeUpgradeStarted,
-		"upgrading pod %s to version %s",
-		meta.Key(pod), aerospikeCluster.Spec.Version)
-
-	// restart the target pod
-	newPod, err := r.safeRestartPodWithIndex(aerospikeCluster, configMap, index, upgrade)
-	if err != nil {
-		return nil, err
-	}
-	// ensure the pod has the target version
-	version, err = getAerospikeServerVersionFromPod(newPod)
-	if err != nil {
-		return nil, err
-	}
-	if version != aerospikeCluster.Spec.Version {
-		r.recorder.Eventf(aerospikeCluster, v1.EventTypeNormal, events.ReasonNodeUpgradeFailed,
-			"failed to upgrade pod %s to version %s",
-			meta.Key(pod), aerospikeCluster.Spec.Version)
-		return nil, fmt.Errorf("failed to upgrade pod %s to version %s", meta.Key(newPod), aerospikeCluster.Spec.Version)
-	}
-
-	log.WithFields(log.Fields{
-		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-	}).Debugf("upgraded pod %s to version %s", meta.Key(pod), aerospikeCluster.Spec.Version)
-	r.recorder.Eventf(aerospikeCluster, v1.EventTypeNormal, events.ReasonNodeUpgradeFinished,
-		"upgraded pod %s to version %s",
-		meta.Key(pod), aerospikeCluster.Spec.Version)
-
-	return newPod, nil
-}
-
-func (r *AerospikeClusterReconciler) signalBackupStarted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
+func (r *AerospikeClusterReconciler) signalBackupStarted(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
-		Type:               common.ConditionAutoBackupStarted,
-		Status:             apiextensions.ConditionTrue,
-		Reason:             events.ReasonClusterAutoBackupStarted,
-		Message:            "cluster backup started",
-		LastTransitionTime: metav1.NewTime(time.Now()),
-	})
+	setCondition(aerospikeCluster, autoBackupConditions, common.ConditionAutoBackupStarted, events.ReasonClusterAutoBackupStarted, "cluster backup started")
 	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusBackupAnnotationValue)
 
-	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
 		return nil, err
 	}
 
@@ -116,20 +53,14 @@ func (r *AerospikeClusterReconciler) signalBackupStarted(aerospikeCluster *aeros
 	return aerospikeCluster, nil
 }
 
-func (r *AerospikeClusterReconciler) signalBackupFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
+func (r *AerospikeClusterReconciler) signalBackupFinished(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
-		Type:               common.ConditionAutoBackupFinished,
-		Status:             apiextensions.ConditionTrue,
-		Reason:             events.ReasonClusterAutoBackupFinished,
-		Message:            "cluster backup finished",
-		LastTransitionTime: metav1.NewTime(time.Now()),
-	})
+	setCondition(aerospikeCluster, autoBackupConditions, common.ConditionAutoBackupFinished, events.ReasonClusterAutoBackupFinished, "cluster backup finished")
 
-	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
 		return nil, err
 	}
 
@@ -143,20 +74,14 @@ func (r *AerospikeClusterReconciler) signalBackupFinished(aerospikeCluster *aero
 	return aerospikeCluster, nil
 }
 
-func (r *AerospikeClusterReconciler) signalBackupFailed(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
+func (r *AerospikeClusterReconciler) signalBackupFailed(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
-		Type:               common.ConditionAutoBackupFailed,
-		Status:             apiextensions.ConditionTrue,
-		Reason:             events.ReasonClusterAutoBackupFailed,
-		Message:            "cluster backup failed",
-		LastTransitionTime: metav1.NewTime(time.Now()),
-	})
+	setCondition(aerospikeCluster, autoBackupConditions, common.ConditionAutoBackupFailed, events.ReasonClusterAutoBackupFailed, "cluster backup failed")
 
-	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
 		return nil, err
 	}
 
@@ -170,21 +95,15 @@ func (r *AerospikeClusterReconciler) signalBackupFailed(aerospikeCluster *aerosp
 	return aerospikeCluster, nil
 }
 
-func (r *AerospikeClusterReconciler) signalUpgradeStarted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
+func (r *AerospikeClusterReconciler) signalUpgradeStarted(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
-		Type:               common.ConditionUpgradeStarted,
-		Status:             apiextensions.ConditionTrue,
-		Reason:             events.ReasonClusterUpgradeStarted,
-		Message:            fmt.Sprintf("upgrade from version %s to %s started", upgrade.Source, upgrade.Target),
-		LastTransitionTime: metav1.NewTime(time.Now()),
-	})
+	setCondition(aerospikeCluster, upgradeConditions, common.ConditionUpgradeStarted, events.ReasonClusterUpgradeStarted, fmt.Sprintf("upgrade from version %s to %s started", upgrade.Source, upgrade.Target))
 	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusStartedAnnotationValue)
 
-	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
 		return nil, err
 	}
 
@@ -198,21 +117,15 @@ func (r *AerospikeClusterReconciler) signalUpgradeStarted(aerospikeCluster *aero
 	return aerospikeCluster, nil
 }
 
-func (r *AerospikeClusterReconciler) signalUpgradeFailed(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
+func (r *AerospikeClusterReconciler) signalUpgradeFailed(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
-		Type:               common.ConditionUpgradeFailed,
-		Status:             apiextensions.ConditionTrue,
-		Reason:             events.ReasonClusterUpgradeFailed,
-		Message:            fmt.Sprintf("upgrade from version %s to %s failed", upgrade.Source, upgrade.Target),
-		LastTransitionTime: metav1.NewTime(time.Now()),
-	})
+	setCondition(aerospikeCluster, upgradeConditions, common.ConditionUpgradeFailed, events.ReasonClusterUpgradeFailed, fmt.Sprintf("upgrade from version %s to %s failed", upgrade.Source, upgrade.Target))
 	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusFailedAnnotationValue)
 
-	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
 		return nil, err
 	}
 
@@ -227,21 +140,15 @@ func (r *AerospikeClusterReconciler) signalUpgradeFailed(aerospikeCluster *aeros
 	return aerospikeCluster, nil
 }
 
-func (r *AerospikeClusterReconciler) signalUpgradeFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
+func (r *AerospikeClusterReconciler) signalUpgradeFinished(ctx context.Context, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
-		Type:               common.ConditionUpgradeFinished,
-		Status:             apiextensions.ConditionTrue,
-		Reason:             events.ReasonClusterUpgradeFinished,
-		Message:            fmt.Sprintf("finished upgrade from version %s to %s", upgrade.Source, upgrade.Target),
-		LastTransitionTime: metav1.NewTime(time.Now()),
-	})
+	setCondition(aerospikeCluster, upgradeConditions, common.ConditionUpgradeFinished, events.ReasonClusterUpgradeFinished, fmt.Sprintf("finished upgrade from version %s to %s", upgrade.Source, upgrade.Target))
 	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey)
 
-	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+	if err := r.patchCluster(ctx, oldCluster, aerospikeCluster); err != nil {
 		return nil, err
 	}
 
@@ -17,58 +17,48 @@ limitations under the License.
 package reconciler
 
 import (
-	"k8s.io/api/core/v1"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
-	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
 )
 
-func (r *AerospikeClusterReconciler) validate(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
-	if !r.validateReplicationFactor(aerospikeCluster) {
-		return false, nil
+// validate validates the fields of aerospikeCluster that cannot be validated
+// statically, and returns an error describing the first problem found (if any).
+func (r *AerospikeClusterReconciler) validate(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	if err := r.validateReplicationFactor(aerospikeCluster); err != nil {
+		return err
 	}
-	if !r.validateStorageClass(aerospikeCluster) {
-		return false, nil
+	if err := r.validateStorageClass(aerospikeCluster); err != nil {
+		return err
 	}
-	return true, nil
+	return nil
 }
 
-func (r *AerospikeClusterReconciler) validateReplicationFactor(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) bool {
+func (r *AerospikeClusterReconciler) validateReplicationFactor(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	for _, ns := range aerospikeCluster.Spec.Namespaces {
 		if ns.ReplicationFactor != nil && *ns.ReplicationFactor > aerospikeCluster.Spec.NodeCount {
-			r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError,
-				"replication factor of %d requested for namespace %s but the cluster has only %d nodes",
-				ns.ReplicationFactor,
+			return fmt.Errorf("replication factor of %d requested for namespace %s but the cluster has only %d nodes",
+				*ns.ReplicationFactor,
 				ns.Name,
 				aerospikeCluster.Spec.NodeCount,
 			)
-			return false
 		}
 	}
-	return true
+	return nil
 }
 
-func (r *AerospikeClusterReconciler) validateStorageClass(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) bool {
+func (r *AerospikeClusterReconciler) validateStorageClass(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	for _, ns := range aerospikeCluster.Spec.Namespaces {
 		if ns.Storage.StorageClassName != nil && *ns.Storage.StorageClassName != "" {
 			if _, err := r.scsLister.Get(*ns.Storage.StorageClassName); err != nil {
 				if errors.IsNotFound(err) {
-					r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError,
-						"storage class %q does not exist",
-						ns.Storage.StorageClassName,
-					)
-				} else {
-					r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError,
-						"failed to get storage class %q: %v",
-						ns.Storage.StorageClassName,
-						err,
-					)
+					return fmt.Errorf("storage class %q does not exist", *ns.Storage.StorageClassName)
 				}
-				return false
+				return fmt.Errorf("failed to get storage class %q: %v", *ns.Storage.StorageClassName, err)
 			}
 		}
 	}
-	return true
+	return nil
 }
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Set adds or updates the condition with the specified type in conditions. The
+// last transition time of an existing condition is only updated if its status
+// changes. Any duplicate conditions with the same type (which older versions of
+// aerospike-operator used to append) are removed.
+func Set(conditions *[]metav1.Condition, conditionType string, status metav1.ConditionStatus, generation int64, reason, message string) {
+	dedupe(conditions, conditionType)
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// SetExclusive sets the condition with the specified type to True and every
+// other condition in group to False, using the same reason and message. It is
+// used for groups of conditions of which at most one should be True at any
+// given time (e.g. UpgradeStarted, UpgradeFinished and UpgradeFailed).
+func SetExclusive(conditions *[]metav1.Condition, group []string, conditionType string, generation int64, reason, message string) {
+	for _, t := range group {
+		status := metav1.ConditionFalse
+		if t == conditionType {
+			status = metav1.ConditionTrue
+		}
+		Set(conditions, t, status, generation, reason, message)
+	}
+}
+
+// dedupe removes all but the most recent condition with the specified type.
+func dedupe(conditions *[]metav1.Condition, conditionType string) {
+	count, last := 0, -1
+	for i := range *conditions {
+		if (*conditions)[i].Type == conditionType {
+			count, last = count+1, i
+		}
+	}
+	if count <= 1 {
+		return
+	}
+	res := make([]metav1.Condition, 0, len(*conditions))
+	for i, c := range *conditions {
+		if c.Type != conditionType || i == last {
+			res = append(res, c)
+		}
+	}
+	*conditions = res
+}
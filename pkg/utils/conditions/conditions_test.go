@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetUpdatesExistingCondition(t *testing.T) {
+	conditions := []metav1.Condition{}
+	Set(&conditions, "Ready", metav1.ConditionFalse, 1, "NodesNotReady", "0/2 nodes are ready")
+	transitionTime := conditions[0].LastTransitionTime
+	Set(&conditions, "Ready", metav1.ConditionFalse, 2, "NodesNotReady", "1/2 nodes are ready")
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, int64(2), conditions[0].ObservedGeneration)
+	assert.Equal(t, "1/2 nodes are ready", conditions[0].Message)
+	assert.Equal(t, transitionTime, conditions[0].LastTransitionTime)
+}
+
+func TestSetRemovesDuplicates(t *testing.T) {
+	conditions := []metav1.Condition{
+		{Type: "UpgradeStarted", Status: metav1.ConditionTrue, Message: "first"},
+		{Type: "BackupFinished", Status: metav1.ConditionTrue},
+		{Type: "UpgradeStarted", Status: metav1.ConditionTrue, Message: "second"},
+	}
+	Set(&conditions, "UpgradeStarted", metav1.ConditionFalse, 3, "ClusterUpgradeFinished", "third")
+	assert.Len(t, conditions, 2)
+	assert.Equal(t, "BackupFinished", conditions[0].Type)
+	assert.Equal(t, "UpgradeStarted", conditions[1].Type)
+	assert.Equal(t, metav1.ConditionFalse, conditions[1].Status)
+	assert.Equal(t, "third", conditions[1].Message)
+}
+
+func TestSetExclusive(t *testing.T) {
+	group := []string{"UpgradeStarted", "UpgradeFinished", "UpgradeFailed"}
+	conditions := []metav1.Condition{}
+	SetExclusive(&conditions, group, "UpgradeStarted", 1, "ClusterUpgradeStarted", "started")
+	SetExclusive(&conditions, group, "UpgradeFinished", 1, "ClusterUpgradeFinished", "finished")
+	assert.Len(t, conditions, 3)
+	for _, c := range conditions {
+		if c.Type == "UpgradeFinished" {
+			assert.Equal(t, metav1.ConditionTrue, c.Status)
+		} else {
+			assert.Equal(t, metav1.ConditionFalse, c.Status)
+		}
+		assert.Equal(t, "ClusterUpgradeFinished", c.Reason)
+	}
+}
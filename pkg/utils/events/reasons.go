@@ -21,6 +21,10 @@ const (
 	// validation errors.
 	ReasonValidationError = "ValidationError"
 
+	// ReasonValidationSucceeded is the reason used in Valid conditions indicating that the spec of
+	// a cluster is valid.
+	ReasonValidationSucceeded = "ValidationSucceeded"
+
 	// ReasonNodeStarting is the reason used in corev1.Event objects created when waiting
 	// for pods to be running and ready.
 	ReasonNodeStarting = "NodeStarting"
@@ -33,6 +37,10 @@ const (
 	// failed to start.
 	ReasonNodeStartedFailed = "NodeStartedFailed"
 
+	// ReasonNodeDeletionFailed is the reason used in corev1.Event objects created when pods are
+	// not deleted in time.
+	ReasonNodeDeletionFailed = "NodeDeletionFailed"
+
 	// ReasonNodeUpgradeStarted is the reason used in corev1.Event objects created when an
 	// upgrade operation starts on a pod.
 	ReasonNodeUpgradeStarted = "NodeUpgradeStarted"
@@ -53,6 +61,10 @@ const (
 	// waiting for migrations to finish.
 	ReasonWaitingForMigrations = "WaitingForMigrations"
 
+	// ReasonClusterNotStable is the reason used in corev1.Event objects and conditions created
+	// when an operation is halted because the cluster did not become stable in time.
+	ReasonClusterNotStable = "ClusterNotStable"
+
 	// ReasonWaitForMigrationsFinished is the reason used in corev1.Event objects created when
 	// migrations are finished.
 	ReasonWaitForMigrationsFinished = "WaitForMigrationsFinished"
@@ -92,4 +104,84 @@ const (
 	// ReasonClusterAutoBackupFailed is the reason used in corev1.Event objects indicating that a
 	// cluster backup has failed
 	ReasonClusterAutoBackupFailed = "ClusterAutoBackupFailed"
+
+	// ReasonNamespaceRemovalBackupStarted is the reason used in corev1.Event objects indicating
+	// that the backup of a namespace being removed from the cluster has started
+	ReasonNamespaceRemovalBackupStarted = "NamespaceRemovalBackupStarted"
+
+	// ReasonNamespaceRemovalBackupFailed is the reason used in corev1.Event objects indicating
+	// that the backup of a namespace being removed from the cluster has failed
+	ReasonNamespaceRemovalBackupFailed = "NamespaceRemovalBackupFailed"
+
+	// ReasonNodeConfigUpdated is the reason used in corev1.Event objects created when dynamic
+	// configuration changes are applied to a pod without restarting it.
+	ReasonNodeConfigUpdated = "NodeConfigUpdated"
+
+	// ReasonNodeConfigUpdateFailed is the reason used in corev1.Event objects created when
+	// dynamic configuration changes cannot be applied to a pod, which is then restarted.
+	ReasonNodeConfigUpdateFailed = "NodeConfigUpdateFailed"
+
+	// ReasonVolumeExpansionStarted is the reason used in corev1.Event objects created when the
+	// expansion of a persistent volume claim is requested.
+	ReasonVolumeExpansionStarted = "VolumeExpansionStarted"
+
+	// ReasonVolumeExpansionFinished is the reason used in corev1.Event objects created when a
+	// persistent volume claim has been expanded.
+	ReasonVolumeExpansionFinished = "VolumeExpansionFinished"
+
+	// ReasonVolumeExpansionFailed is the reason used in corev1.Event objects created when a
+	// persistent volume claim could not be expanded in time.
+	ReasonVolumeExpansionFailed = "VolumeExpansionFailed"
+
+	// ReasonStorageMigrationStarted is the reason used in corev1.Event objects created when a pod
+	// is restarted in order to migrate its storage to a different storage type or storage class.
+	ReasonStorageMigrationStarted = "StorageMigrationStarted"
+
+	// ReasonStorageMigrationFinished is the reason used in corev1.Event objects created when the
+	// storage of a pod has been migrated and data has been migrated back to it.
+	ReasonStorageMigrationFinished = "StorageMigrationFinished"
+
+	// ReasonStorageMigrationFailed is the reason used in corev1.Event objects created when the
+	// storage migration of a pod fails.
+	ReasonStorageMigrationFailed = "StorageMigrationFailed"
+
+	// ReasonNodeReplacementStarted is the reason used in corev1.Event objects created when a pod
+	// is re-created with new persistent volume claims at the user's request.
+	ReasonNodeReplacementStarted = "NodeReplacementStarted"
+
+	// ReasonNodeReplacementFinished is the reason used in corev1.Event objects created when a pod
+	// has been replaced and data has been migrated back to it.
+	ReasonNodeReplacementFinished = "NodeReplacementFinished"
+
+	// ReasonReplicationFactorChangeStarted is the reason used in corev1.Event objects indicating
+	// that a change to the replication factor of an Aerospike namespace has started
+	ReasonReplicationFactorChangeStarted = "ReplicationFactorChangeStarted"
+
+	// ReasonReplicationFactorChangeFinished is the reason used in corev1.Event objects indicating
+	// that a change to the replication factor of an Aerospike namespace has finished
+	ReasonReplicationFactorChangeFinished = "ReplicationFactorChangeFinished"
+
+	// ReasonReconciliationPaused is the reason used in corev1.Event objects and Paused conditions
+	// indicating that reconciliation of a cluster is paused
+	ReasonReconciliationPaused = "ReconciliationPaused"
+
+	// ReasonReconciliationResumed is the reason used in corev1.Event objects and Paused conditions
+	// indicating that reconciliation of a cluster has been resumed
+	ReasonReconciliationResumed = "ReconciliationResumed"
+
+	// ReasonNodesReady is the reason used in Ready conditions indicating that every Aerospike node
+	// is running and ready
+	ReasonNodesReady = "NodesReady"
+
+	// ReasonNodesNotReady is the reason used in Ready conditions indicating that some Aerospike
+	// nodes are missing or not ready
+	ReasonNodesNotReady = "NodesNotReady"
+
+	// ReasonReconcileFailed is the reason used in Degraded conditions indicating that the last
+	// attempt at reconciling a cluster has failed
+	ReasonReconcileFailed = "ReconcileFailed"
+
+	// ReasonAsExpected is the reason used in Degraded conditions indicating that nothing is wrong
+	// with a cluster
+	ReasonAsExpected = "AsExpected"
 )
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reserved
+
+import (
+	"strings"
+)
+
+const (
+	// InitContainerName is the name of the init container of every pod.
+	InitContainerName = "init"
+	// AerospikeServerContainerName is the name of the container running aerospike.
+	AerospikeServerContainerName = "aerospike-server"
+	// AspromContainerName is the name of the container running the metrics exporter.
+	AspromContainerName = "asprom"
+
+	// InitialConfigVolumeName is the name of the volume holding aerospike.conf before templating.
+	InitialConfigVolumeName = "aerospike-conf-src"
+	// FinalConfigVolumeName is the name of the volume holding aerospike.conf after templating.
+	FinalConfigVolumeName = "aerospike-conf"
+	// NamespaceVolumePrefix is the prefix of the names of the volumes holding namespace data.
+	NamespaceVolumePrefix = "data-ns"
+
+	// ServicePort is the port on which aerospike serves clients.
+	ServicePort = 3000
+	// FabricPort is the port used by aerospike for intra-cluster communication.
+	FabricPort = 3001
+	// HeartbeatPort is the port used by aerospike for heartbeats.
+	HeartbeatPort = 3002
+	// InfoPort is the port on which aerospike serves info commands.
+	InfoPort = 3003
+)
+
+// IsContainerName returns whether the specified name is used by one of the containers managed by aerospike-operator.
+func IsContainerName(name string) bool {
+	return name == InitContainerName || name == AerospikeServerContainerName || name == AspromContainerName
+}
+
+// IsVolumeName returns whether the specified name is used (or may be used) by one of the volumes managed by
+// aerospike-operator.
+func IsVolumeName(name string) bool {
+	return name == InitialConfigVolumeName || name == FinalConfigVolumeName || strings.HasPrefix(name, NamespaceVolumePrefix+"-")
+}
+
+// IsPort returns whether the specified port is used by aerospike, and hence cannot be used by the metrics exporter.
+func IsPort(port int32) bool {
+	return port == ServicePort || port == FabricPort || port == HeartbeatPort || port == InfoPort
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reserved
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsContainerName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"init", true},
+		{"aerospike-server", true},
+		{"asprom", true},
+		{"sidecar", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, IsContainerName(test.name))
+	}
+}
+
+func TestIsVolumeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"aerospike-conf", true},
+		{"aerospike-conf-src", true},
+		{"data-ns-test", true},
+		{"data-ns", false},
+		{"scratch", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, IsVolumeName(test.name))
+	}
+}
+
+func TestIsPort(t *testing.T) {
+	tests := []struct {
+		port     int32
+		expected bool
+	}{
+		{3000, true},
+		{3001, true},
+		{3002, true},
+		{3003, true},
+		{9145, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, IsPort(test.port))
+	}
+}
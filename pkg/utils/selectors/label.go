@@ -31,6 +31,8 @@ const (
 	LabelClusterKey = "cluster"
 	// LabelNamespaceKey represents the name of the "namespace" label added to every persistent volume claim.
 	LabelNamespaceKey = "namespace"
+	// LabelNodeGroupKey represents the name of the "node-group" label added to pods belonging to a node group.
+	LabelNodeGroupKey = "node-group"
 )
 
 // ResourcesByClusterName returns a selector that matches all resources belonging to a given AerospikeCluster.
@@ -0,0 +1,35 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclasses
+
+import (
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+const (
+	// DefaultStorageClassAnnotation is the name of the annotation used to mark a storage class as the default one.
+	// https://kubernetes.io/docs/tasks/administer-cluster/change-default-storage-class/
+	DefaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// BetaDefaultStorageClassAnnotation is the name of the deprecated annotation used to mark a storage class as the
+	// default one.
+	BetaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// IsDefault returns whether the provided storage class is marked as the default one.
+func IsDefault(sc *storagev1.StorageClass) bool {
+	return sc.Annotations[DefaultStorageClassAnnotation] == "true" || sc.Annotations[BetaDefaultStorageClassAnnotation] == "true"
+}
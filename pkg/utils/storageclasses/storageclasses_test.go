@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclasses
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newStorageClass(name string, annotations map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestIsDefault(t *testing.T) {
+	tests := []struct {
+		provided *storagev1.StorageClass
+		expected bool
+	}{
+		{newStorageClass("standard", nil), false},
+		{newStorageClass("standard", map[string]string{DefaultStorageClassAnnotation: "false"}), false},
+		{newStorageClass("standard", map[string]string{DefaultStorageClassAnnotation: "true"}), true},
+		{newStorageClass("standard", map[string]string{BetaDefaultStorageClassAnnotation: "true"}), true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, IsDefault(test.provided))
+	}
+}
@@ -56,6 +56,21 @@ func (v Version) String() string {
 	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, v.Revision)
 }
 
+// IsAtLeast indicates whether the version of Aerospike represented by the
+// current struct is the same as or newer than the specified one.
+func (v Version) IsAtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	if v.Patch != other.Patch {
+		return v.Patch > other.Patch
+	}
+	return v.Revision >= other.Revision
+}
+
 // IsSupported indicated whether the version of Aerospike represented by the
 // current struct is supported by the operator.
 func (v Version) IsSupported() bool {
@@ -55,3 +55,22 @@ func TestString(t *testing.T) {
 		assert.Equal(t, test.version.String(), test.versionString)
 	}
 }
+
+func TestIsAtLeast(t *testing.T) {
+	tests := []struct {
+		version Version
+		other   Version
+		result  bool
+	}{
+		{Version{4, 3, 1, 3}, Version{4, 3, 1, 3}, true},
+		{Version{4, 3, 1, 4}, Version{4, 3, 1, 3}, true},
+		{Version{4, 4, 0, 0}, Version{4, 3, 1, 3}, true},
+		{Version{5, 0, 0, 0}, Version{4, 3, 1, 3}, true},
+		{Version{4, 3, 0, 10}, Version{4, 3, 1, 3}, false},
+		{Version{4, 2, 5, 5}, Version{4, 3, 1, 3}, false},
+		{Version{3, 16, 0, 6}, Version{4, 3, 1, 3}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.result, test.version.IsAtLeast(test.other))
+	}
+}
@@ -84,23 +84,45 @@ func testCreateAerospikeClusterWithNineNodes(tf *framework.TestFramework, ns *co
 	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("spec.nodeCount.*should be less than or equal to 8")))
 }
 
+func testCreateAerospikeClusterWithZeroStabilizationTimeout(tf *framework.TestFramework, ns *corev1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.Rollout = &aerospikev1alpha2.RolloutSpec{
+		StabilizationTimeout: &metav1.Duration{},
+	}
+	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).To(HaveOccurred())
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the stabilization timeout must be greater than zero")))
+}
+
 func testCreateAerospikeClusterWithZeroNamespaces(tf *framework.TestFramework, ns *corev1.Namespace) {
 	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
 	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{}
 	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
 	Expect(err).To(HaveOccurred())
-	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the number of namespaces in the cluster must be exactly one")))
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the cluster must have at least one namespace")))
 }
 
-func testCreateAerospikeClusterWithTwoNamespaces(tf *framework.TestFramework, ns *corev1.Namespace) {
+func testCreateAerospikeClusterWithThreeNamespaces(tf *framework.TestFramework, ns *corev1.Namespace) {
 	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
 	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{
 		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, 1),
 		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-1", 1, 1, 0, 1),
+		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-2", 1, 1, 0, 1),
+	}
+	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).To(HaveOccurred())
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the number of namespaces in the cluster cannot exceed \\d+")))
+}
+
+func testCreateAerospikeClusterWithDuplicateNamespaces(tf *framework.TestFramework, ns *corev1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{
+		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, 1),
+		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, 1),
 	}
 	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
 	Expect(err).To(HaveOccurred())
-	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the number of namespaces in the cluster must be exactly one")))
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("namespace names must be unique")))
 }
 
 func testCreateAerospikeClusterWithInvalidReplicationFactor(tf *framework.TestFramework, ns *corev1.Namespace) {
@@ -129,7 +151,7 @@ func testCreateAerospikeClusterWithNodeCount(tf *framework.TestFramework, ns *co
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(len(pods.Items))).To(Equal(nodeCount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(nodeCount))
 }
@@ -161,7 +183,7 @@ func testCreateAerospikeClusterWithResources(tf *framework.TestFramework, ns *co
 	Expect(pods.Items[0].Spec.Containers[0].Resources.Limits.Cpu()).To(Equal(aerospikeCluster.Spec.Resources.Limits.Cpu()))
 	Expect(pods.Items[0].Spec.Containers[0].Resources.Limits.Memory()).To(Equal(aerospikeCluster.Spec.Resources.Limits.Memory()))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(int32(1)))
 }
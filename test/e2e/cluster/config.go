@@ -0,0 +1,142 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testCreateAerospikeClusterWithReservedConfigKey(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.AerospikeConfig = &aerospikev1alpha2.AerospikeConfigSpec{
+		Network: map[string]string{
+			"heartbeat.port": "3005",
+		},
+	}
+	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).To(HaveOccurred())
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("key \"heartbeat.port\" in .spec.aerospikeConfig.network is managed by aerospike-operator")))
+}
+
+func testCreateAerospikeClusterWithAerospikeConfig(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.AerospikeConfig = &aerospikev1alpha2.AerospikeConfigSpec{
+		Service: map[string]string{
+			"migrate-threads": "2",
+		},
+		Network: map[string]string{
+			"heartbeat.interval": "150",
+		},
+		Namespace: map[string]string{
+			"high-water-disk-pct": "60",
+		},
+	}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, aerospikeCluster.Spec.NodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	c, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Close()
+
+	service, err := c.GetConfig("service")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(service["migrate-threads"]).To(Equal("2"))
+
+	network, err := c.GetConfig("network")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(network["heartbeat.interval"]).To(Equal("150"))
+
+	namespace, err := c.GetConfig(fmt.Sprintf("namespace;id=%s", aerospikeCluster.Spec.Namespaces[0].Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(namespace["high-water-disk-pct"]).To(Equal("60"))
+}
+
+func testUpdateDynamicAerospikeConfig(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 2
+	aerospikeCluster.Spec.AerospikeConfig = &aerospikev1alpha2.AerospikeConfigSpec{
+		Namespace: map[string]string{
+			"high-water-disk-pct": "60",
+		},
+	}
+	aerospikeCluster.Spec.Namespaces[0].AerospikeConfig = map[string]string{
+		"high-water-memory-pct": "60",
+	}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, aerospikeCluster.Spec.NodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// grab the uids of the pods before the update
+	before, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	uids := make([]string, 0, len(before.Items))
+	for _, pod := range before.Items {
+		uids = append(uids, string(pod.UID))
+	}
+
+	// change the values of dynamic properties only
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	res.Spec.AerospikeConfig.Namespace["high-water-disk-pct"] = "70"
+	res.Spec.Namespaces[0].AerospikeConfig["high-water-memory-pct"] = "65"
+	res.Spec.AerospikeConfig.Network = map[string]string{
+		"heartbeat.interval": "150",
+	}
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	c, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Close()
+
+	// wait for the new values to be applied
+	Eventually(func() (string, error) {
+		namespace, err := c.GetConfig(fmt.Sprintf("namespace;id=%s", res.Spec.Namespaces[0].Name))
+		return namespace["high-water-disk-pct"], err
+	}, 5*time.Minute, 5*time.Second).Should(Equal("70"))
+	Eventually(func() (string, error) {
+		namespace, err := c.GetConfig(fmt.Sprintf("namespace;id=%s", res.Spec.Namespaces[0].Name))
+		return namespace["high-water-memory-pct"], err
+	}, 5*time.Minute, 5*time.Second).Should(Equal("65"))
+	Eventually(func() (string, error) {
+		network, err := c.GetConfig("network")
+		return network["heartbeat.interval"], err
+	}, 5*time.Minute, 5*time.Second).Should(Equal("150"))
+
+	// make sure that no pod has been restarted
+	after, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(after.Items).To(HaveLen(len(uids)))
+	for _, pod := range after.Items {
+		Expect(uids).To(ContainElement(string(pod.UID)))
+	}
+}
@@ -61,15 +61,30 @@ var _ = Describe("AerospikeCluster", func() {
 		It("cannot be created with spec.nodeCount==9", func() {
 			testCreateAerospikeClusterWithNineNodes(tf, ns)
 		})
+		It("cannot be created with a zero spec.rollout.stabilizationTimeout", func() {
+			testCreateAerospikeClusterWithZeroStabilizationTimeout(tf, ns)
+		})
 		It("cannot be created with len(spec.namespaces)==0", func() {
 			testCreateAerospikeClusterWithZeroNamespaces(tf, ns)
 		})
-		It("cannot be created with len(spec.namespaces)==2", func() {
-			testCreateAerospikeClusterWithTwoNamespaces(tf, ns)
+		It("cannot be created with len(spec.namespaces)==3", func() {
+			testCreateAerospikeClusterWithThreeNamespaces(tf, ns)
+		})
+		It("cannot be created with duplicate spec.namespaces[*].name", func() {
+			testCreateAerospikeClusterWithDuplicateNamespaces(tf, ns)
 		})
 		It("cannot be created if spec.namespaces.replicationFactor[*] > spec.nodeCount", func() {
 			testCreateAerospikeClusterWithInvalidReplicationFactor(tf, ns)
 		})
+		It("cannot be created if spec.aerospikeConfig contains a key managed by aerospike-operator", func() {
+			testCreateAerospikeClusterWithReservedConfigKey(tf, ns)
+		})
+		It("is created with the provided spec.aerospikeConfig", func() {
+			testCreateAerospikeClusterWithAerospikeConfig(tf, ns)
+		})
+		It("applies changes to dynamic properties in spec.aerospikeConfig without restarting pods", func() {
+			testUpdateDynamicAerospikeConfig(tf, ns)
+		})
 		It("is created with the provided spec.nodeCount", func() {
 			testCreateAerospikeClusterWithNodeCount(tf, ns, 2)
 		})
@@ -88,9 +103,84 @@ var _ = Describe("AerospikeCluster", func() {
 		It("supports file storage", func() {
 			testFileStorage(tf, ns, 1, 2)
 		})
+		It("supports multiple namespaces", func() {
+			testMultipleNamespaces(tf, ns, 2, 1)
+		})
+		It("supports expanding the storage of a namespace", func() {
+			testExpandNamespaceStorage(tf, ns, 2, 1)
+		})
+		It("supports migrating the storage of a namespace from file to device", func() {
+			testMigrateNamespaceStorage(tf, ns, 2, 10000)
+		})
+		It("cannot migrate the storage of a namespace that is not replicated", func() {
+			testMigrateNamespaceStorageWithoutReplication(tf, ns)
+		})
+		It("supports adding a namespace to a live cluster", func() {
+			testAddNamespace(tf, ns, 2, 1000)
+		})
+		It("supports removing a namespace from a live cluster", func() {
+			testRemoveNamespace(tf, ns, 2, 1000)
+		})
+		It("supports changing the replication factor of a namespace", func() {
+			testChangeReplicationFactor(tf, ns, 2, 1000)
+		})
+		It("supports moving nodes between node groups", func() {
+			testNodeGroups(tf, ns, 1000)
+		})
+		It("adopts existing pods when node groups are defined", func() {
+			testNodeGroupsAddedToExistingCluster(tf, ns, 1000)
+		})
+		It("cannot be created with node groups not adding up to spec.nodeCount", func() {
+			testNodeGroupsWithMismatchingNodeCount(tf, ns)
+		})
+		It("spreads nodes across the provided spec.racks", func() {
+			testCreateAerospikeClusterWithRacks(tf, ns)
+		})
+		It("cannot be created with duplicate rack ids", func() {
+			testCreateAerospikeClusterWithDuplicateRackIds(tf, ns)
+		})
+		It("can have racks added", func() {
+			testAddAerospikeClusterRacks(tf, ns)
+		})
+		It("cannot have its racks removed", func() {
+			testRemoveAerospikeClusterRacks(tf, ns)
+		})
+		It("merges spec.podTemplate into the generated pods", func() {
+			testCreateAerospikeClusterWithPodTemplate(tf, ns)
+		})
+		It("cannot be created with a pod template using a reserved container name", func() {
+			testCreateAerospikeClusterWithReservedContainerName(tf, ns)
+		})
+		It("uses the images specified in spec.images", func() {
+			testCreateAerospikeClusterWithImages(tf, ns)
+		})
+		It("serves metrics on the port specified in spec.monitoring", func() {
+			testCreateAerospikeClusterWithMonitoringPort(tf, ns)
+		})
+		It("does not run the metrics exporter when disabled", func() {
+			testCreateAerospikeClusterWithMonitoringDisabled(tf, ns)
+		})
+		It("cannot be created with the metrics exporter using a reserved port", func() {
+			testCreateAerospikeClusterWithReservedMonitoringPort(tf, ns)
+		})
+		It("has a pod disruption budget matching the replication factor", func() {
+			testPodDisruptionBudgetFollowsReplicationFactor(tf, ns)
+		})
+		It("reports the state of every node in its status", func() {
+			testClusterStatusReportsNodes(tf, ns)
+		})
+		It("reports validation errors in its status", func() {
+			testClusterStatusReportsValidationErrors(tf, ns)
+		})
+		It("is not reconciled while spec.paused is true", func() {
+			testPausedClusterIsNotReconciled(tf, ns)
+		})
 		It("reuses the persistent volume of a deleted pod", func() {
 			testVolumeIsReused(tf, ns, 2)
 		})
+		It("replaces a single pod with a new persistent volume when requested", func() {
+			testReplacePod(tf, ns, 2, 10000)
+		})
 		It("has the correct number of nodes after scaling up", func() {
 			testNodeCountAfterScaling(tf, ns, 1, 3)
 		})
@@ -136,6 +226,12 @@ var _ = Describe("AerospikeCluster", func() {
 		It("makes pre-upgrade backups, re-uses persistent volumes, and does not lose data in a namespace after an upgrade from 4.2.0.10 to 4.3.0.10", func() {
 			testReusePVCsAndNoDataLossOnAerospikeUpgrade(tf, ns, 2, 10000, "4.2.0.10", "4.3.0.10")
 		})
+		It("restarts pods after a change to their spec", func() {
+			testPodsRestartedAfterPodSpecChange(tf, ns, 2)
+		})
+		It("restarts every pod after a change to spec.restartGeneration", func() {
+			testPodsRestartedAfterRestartGenerationChange(tf, ns, 2)
+		})
 		It("node IDs are kept after restart", func() {
 			testNodeIDsAfterRestart(tf, ns, 2)
 		})
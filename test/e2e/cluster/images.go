@@ -0,0 +1,60 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testCreateAerospikeClusterWithImages(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 1
+	aerospikeCluster.Spec.Images = &aerospikev1alpha2.ImagesSpec{
+		AerospikeServer: &aerospikev1alpha2.ImageSpec{
+			Repository: "docker.io/aerospike/aerospike-server",
+		},
+		PullPolicy: v1.PullIfNotPresent,
+		PullSecrets: []v1.LocalObjectReference{
+			{Name: "registry-credentials"},
+		},
+	}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 1)
+	Expect(err).NotTo(HaveOccurred())
+
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(pods.Items).To(HaveLen(1))
+	pod := pods.Items[0]
+	Expect(pod.Spec.ImagePullSecrets).To(Equal(aerospikeCluster.Spec.Images.PullSecrets))
+	Expect(pod.Spec.Containers[0].Image).To(Equal("docker.io/aerospike/aerospike-server:" + aerospikeCluster.Spec.Version))
+	Expect(pod.Spec.Containers[1].Image).To(Equal(images.Tools(nil)))
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		Expect(container.ImagePullPolicy).To(Equal(v1.PullIfNotPresent))
+	}
+}
@@ -56,11 +56,11 @@ func testClusterSizeAfterScalingDownClusterWhileStartingAnother(tf *framework.Te
 	Expect(err).NotTo(HaveOccurred())
 	Expect(asc2.Status.NodeCount).To(Equal(nodeCount))
 
-	size1, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc1.Name, asc1.Namespace), 3000)
+	size1, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc1.Name, asc1.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(size1)).To(Equal(nodeCount))
 
-	size2, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc2.Name, asc2.Namespace), 3000)
+	size2, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc2.Name, asc2.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(size2)).To(Equal(nodeCount))
 }
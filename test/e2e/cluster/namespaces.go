@@ -0,0 +1,181 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/reconciler"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testAddNamespace(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, nRecords int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	aerospikeCluster.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(2)
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	ns1 := aerospikeCluster.Spec.Namespaces[0]
+	c1, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c1.WriteSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c1.Close()
+
+	ns2 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-1", 2, 1, 0, 1)
+	err = tf.ChangeNamespacesAndWait(res, []aerospikev1alpha2.AerospikeNamespaceSpec{ns1, ns2})
+	Expect(err).NotTo(HaveOccurred())
+
+	// every pod must have been given a pvc for the new namespace
+	Expect(pvcsByNamespace(tf, ns, res, nodeCount)).To(Equal(map[string]int{ns1.Name: int(nodeCount), ns2.Name: int(nodeCount)}))
+
+	c2, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c2.ReadSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	err = c2.WriteSequentialIntegers(ns2.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c2.Close()
+}
+
+func testRemoveNamespace(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, nRecords int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	ns1 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 2, 1, 0, 1)
+	ns2 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-1", 2, 1, 0, 1)
+	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{ns1, ns2}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	c1, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c1.WriteSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c1.Close()
+
+	err = tf.ChangeNamespacesAndWait(res, []aerospikev1alpha2.AerospikeNamespaceSpec{ns1})
+	Expect(err).NotTo(HaveOccurred())
+
+	// pods must no longer mount a pvc for the removed namespace
+	Expect(pvcsByNamespace(tf, ns, res, nodeCount)).To(Equal(map[string]int{ns1.Name: int(nodeCount)}))
+
+	// the pvcs for the removed namespace must have been marked as unmounted
+	pvcs, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	for _, pvc := range pvcs.Items {
+		if pvc.Labels[selectors.LabelNamespaceKey] == ns2.Name {
+			Expect(pvc.Annotations).To(HaveKey(reconciler.LastUnmountedOnAnnotation))
+		}
+	}
+
+	c2, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c2.ReadSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c2.Close()
+}
+
+// pvcsByNamespace returns the number of pvcs mounted by the pods in the
+// specified cluster, grouped by aerospike namespace.
+func testChangeReplicationFactor(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, nRecords int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	aerospikeCluster.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(1)
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	ns1 := aerospikeCluster.Spec.Namespaces[0]
+	c1, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c1.WriteSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c1.Close()
+
+	// wait for the status to reflect the initial replication factor
+	Eventually(func() (int, error) {
+		res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+		return len(res.Status.Namespaces), err
+	}, 5*time.Minute, 5*time.Second).Should(Equal(1))
+
+	// increase the replication factor
+	res.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(2)
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// wait for the change to be finished
+	Eventually(func() (bool, error) {
+		res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return apimeta.IsStatusConditionTrue(res.Status.Conditions, common.ConditionReplicationFactorChangeFinished), nil
+	}, 20*time.Minute, 10*time.Second).Should(BeTrue())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure that aerospike is using the new replication factor and that
+	// no data has been lost
+	c2, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c2.Close()
+	config, err := c2.GetConfig(fmt.Sprintf("namespace;id=%s", ns1.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(config["replication-factor"]).To(Equal("2"))
+	err = c2.ReadSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func pvcsByNamespace(tf *framework.TestFramework, ns *v1.Namespace, aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nodeCount int32) map[string]int {
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(aerospikeCluster.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(int32(len(pods.Items))).To(Equal(nodeCount))
+
+	res := make(map[string]int)
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if c := volume.VolumeSource.PersistentVolumeClaim; c != nil {
+				claim, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).Get(context.TODO(), c.ClaimName, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				res[claim.Labels[selectors.LabelNamespaceKey]]++
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testNodeGroups(tf *framework.TestFramework, ns *v1.Namespace, nRecords int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 2
+	aerospikeCluster.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(2)
+	aerospikeCluster.Spec.NodeGroups = []aerospikev1alpha2.NodeGroupSpec{
+		{Name: "group-a", NodeCount: 2},
+		{Name: "group-b", NodeCount: 0},
+	}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 2)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(nodeGroupSizes(tf, ns, res)).To(Equal(map[string]int{"group-a": 2}))
+
+	ns1 := aerospikeCluster.Spec.Namespaces[0]
+	c1, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c1.WriteSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c1.Close()
+
+	// move one node from group-a to group-b
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	res.Spec.NodeGroups[0].NodeCount = 1
+	res.Spec.NodeGroups[1].NodeCount = 1
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	Eventually(func() map[string]int {
+		return nodeGroupSizes(tf, ns, res)
+	}, 20*time.Minute, 10*time.Second).Should(Equal(map[string]int{"group-a": 1, "group-b": 1}))
+
+	err = tf.WaitForClusterNodeCount(res, 2)
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure that no data has been lost
+	c2, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c2.Close()
+	err = c2.ReadSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func testNodeGroupsAddedToExistingCluster(tf *framework.TestFramework, ns *v1.Namespace, nRecords int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 2
+	aerospikeCluster.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(2)
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 2)
+	Expect(err).NotTo(HaveOccurred())
+
+	ns1 := aerospikeCluster.Spec.Namespaces[0]
+	c1, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c1.WriteSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c1.Close()
+
+	// define node groups for the existing pods
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	res.Spec.NodeGroups = []aerospikev1alpha2.NodeGroupSpec{
+		{Name: "group-a", NodeCount: 1},
+		{Name: "group-b", NodeCount: 1},
+	}
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// the existing pods must be adopted (and restarted in place) rather than
+	// replaced by pods with new indexes
+	Eventually(func() map[string]int {
+		pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(pods.Items)).To(BeNumerically("<=", 2))
+		for _, pod := range pods.Items {
+			Expect(pod.Name).To(BeElementOf(fmt.Sprintf("%s-0", res.Name), fmt.Sprintf("%s-1", res.Name)))
+		}
+		return nodeGroupSizes(tf, ns, res)
+	}, 20*time.Minute, 10*time.Second).Should(Equal(map[string]int{"group-a": 1, "group-b": 1}))
+
+	err = tf.WaitForClusterNodeCount(res, 2)
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure that no data has been lost
+	c2, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c2.Close()
+	err = c2.ReadSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func testNodeGroupsWithMismatchingNodeCount(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 2
+	aerospikeCluster.Spec.NodeGroups = []aerospikev1alpha2.NodeGroupSpec{
+		{Name: "group-a", NodeCount: 1},
+	}
+	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).To(HaveOccurred())
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the node counts of the node groups add up to")))
+}
+
+// nodeGroupSizes returns the number of running pods in each node group of the
+// specified cluster.
+func nodeGroupSizes(tf *framework.TestFramework, ns *v1.Namespace, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) map[string]int {
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(aerospikeCluster.Name))
+	Expect(err).NotTo(HaveOccurred())
+	res := make(map[string]int)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil {
+			res[pod.Labels[selectors.LabelNodeGroupKey]]++
+		}
+	}
+	return res
+}
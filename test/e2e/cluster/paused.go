@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testPausedClusterIsNotReconciled(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 1)
+	Expect(err).NotTo(HaveOccurred())
+
+	// pause reconciliation and scale the cluster up
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	res.Spec.Paused = true
+	res.Spec.NodeCount = 2
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure the pause is reported and that no pods are created
+	Eventually(func() (bool, error) {
+		res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return apimeta.IsStatusConditionTrue(res.Status.Conditions, common.ConditionPaused), nil
+	}, 2*time.Minute, 5*time.Second).Should(BeTrue())
+	Consistently(func() (int, error) {
+		pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+		if err != nil {
+			return 0, err
+		}
+		return len(pods.Items), nil
+	}, 1*time.Minute, 5*time.Second).Should(Equal(1))
+	Expect(res.Status.NodeCount).To(Equal(int32(1)))
+
+	// resume reconciliation and wait for the cluster to be scaled up
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	res.Spec.Paused = false
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 2)
+	Expect(err).NotTo(HaveOccurred())
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testPodDisruptionBudgetFollowsReplicationFactor(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 3
+	aerospikeCluster.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(2)
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 3)
+	Expect(err).NotTo(HaveOccurred())
+
+	pdb, err := tf.KubeClient.PolicyV1().PodDisruptionBudgets(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(pdb.Spec.MaxUnavailable.IntValue()).To(Equal(1))
+	Expect(pdb.OwnerReferences).To(HaveLen(1))
+	Expect(pdb.OwnerReferences[0].UID).To(Equal(res.UID))
+
+	// increase the replication factor and make sure the pod disruption budget
+	// is updated accordingly
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	res.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(3)
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	Eventually(func() (int, error) {
+		pdb, err := tf.KubeClient.PolicyV1().PodDisruptionBudgets(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, err
+		}
+		return pdb.Spec.MaxUnavailable.IntValue(), nil
+	}, 20*time.Minute, 10*time.Second).Should(Equal(2))
+}
@@ -19,6 +19,7 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"k8s.io/api/core/v1"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/travelaudience/aerospike-operator/pkg/asutils"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
 	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
 )
 
@@ -44,7 +46,7 @@ func testNodeCountAfterRestart(tf *framework.TestFramework, ns *v1.Namespace, no
 	Expect(err).NotTo(HaveOccurred())
 	Expect(asc.Status.NodeCount).To(Equal(nodeCount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(nodeCount))
 }
@@ -65,7 +67,7 @@ func testNodeCountAfterRestartAndScaling(tf *framework.TestFramework, ns *v1.Nam
 	Expect(err).NotTo(HaveOccurred())
 	Expect(asc.Status.NodeCount).To(Equal(finalNodeCount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(finalNodeCount))
 }
@@ -119,7 +121,7 @@ func testNoDataLossAfterRestartAndScaleDown(tf *framework.TestFramework, ns *v1.
 	Expect(err).NotTo(HaveOccurred())
 	err = tf.ScaleCluster(asc, nodeCount)
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(nodeCount))
 
@@ -161,3 +163,90 @@ func testNodeIDsAfterRestart(tf *framework.TestFramework, ns *v1.Namespace, node
 		Expect(found).To(Equal(true))
 	}
 }
+
+func testPodsRestartedAfterPodSpecChange(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	asc, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(asc, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// add a toleration, which does not change the aerospike configuration
+	toleration := v1.Toleration{
+		Key:      "dedicated",
+		Operator: v1.TolerationOpEqual,
+		Value:    "aerospike",
+		Effect:   v1.TaintEffectNoSchedule,
+	}
+	asc, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(asc.Namespace).Get(context.TODO(), asc.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	asc.Spec.Tolerations = []v1.Toleration{toleration}
+	asc, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(asc.Namespace).Update(context.TODO(), asc, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// every pod must eventually be re-created with the toleration
+	Eventually(func() int {
+		pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(asc.Name))
+		Expect(err).NotTo(HaveOccurred())
+		count := 0
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil {
+				for _, t := range pod.Spec.Tolerations {
+					if t == toleration {
+						count++
+					}
+				}
+			}
+		}
+		return count
+	}, 20*time.Minute, 10*time.Second).Should(Equal(int(nodeCount)))
+
+	err = tf.WaitForClusterNodeCount(asc, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func testPodsRestartedAfterRestartGenerationChange(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	asc, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(asc, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(asc.Name))
+	Expect(err).NotTo(HaveOccurred())
+	uids := make(map[string]bool)
+	for _, pod := range pods.Items {
+		uids[string(pod.UID)] = true
+	}
+
+	// request a rolling restart without changing anything else
+	asc, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(asc.Namespace).Get(context.TODO(), asc.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	asc.Spec.RestartGeneration = 1
+	asc, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(asc.Namespace).Update(context.TODO(), asc, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// every pod must eventually be re-created
+	Eventually(func() (int64, error) {
+		asc, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(asc.Namespace).Get(context.TODO(), asc.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, err
+		}
+		return asc.Status.RestartGeneration, nil
+	}, 20*time.Minute, 10*time.Second).Should(Equal(int64(1)))
+
+	pods, err = tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(asc.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(pods.Items).To(HaveLen(int(nodeCount)))
+	for _, pod := range pods.Items {
+		Expect(uids).NotTo(HaveKey(string(pod.UID)))
+	}
+
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(int32(clusterSize)).To(Equal(nodeCount))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testCreateAerospikeClusterWithPodTemplate(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 2
+	aerospikeCluster.Spec.PodTemplate = &aerospikev1alpha2.PodTemplateSpec{
+		Labels: map[string]string{
+			"team": "data",
+		},
+		AntiAffinity: common.PodAntiAffinitySoft,
+		Volumes: []v1.Volume{
+			{
+				Name: "scratch",
+				VolumeSource: v1.VolumeSource{
+					EmptyDir: &v1.EmptyDirVolumeSource{},
+				},
+			},
+		},
+		Containers: []v1.Container{
+			{
+				Name:    "sidecar",
+				Image:   "busybox:1.36",
+				Command: []string{"sleep", "infinity"},
+				VolumeMounts: []v1.VolumeMount{
+					{
+						Name:      "scratch",
+						MountPath: "/scratch",
+					},
+				},
+			},
+		},
+	}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 2)
+	Expect(err).NotTo(HaveOccurred())
+
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(pods.Items).To(HaveLen(2))
+	for _, pod := range pods.Items {
+		Expect(pod.Labels).To(HaveKeyWithValue("team", "data"))
+		Expect(pod.Spec.Containers).To(HaveLen(3))
+		Expect(pod.Spec.Containers[2].Name).To(Equal("sidecar"))
+		Expect(pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+		Expect(pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+	}
+}
+
+func testCreateAerospikeClusterWithReservedContainerName(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.PodTemplate = &aerospikev1alpha2.PodTemplateSpec{
+		Containers: []v1.Container{
+			{
+				Name:  "aerospike-server",
+				Image: "busybox:1.36",
+			},
+		},
+	}
+	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).To(HaveOccurred())
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("container name \"aerospike-server\" is reserved")))
+}
@@ -20,13 +20,16 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/reconciler"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/listoptions"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
@@ -112,6 +115,53 @@ func testFileStorage(tf *framework.TestFramework, ns *v1.Namespace, nodeCount in
 	}
 }
 
+func testMultipleNamespaces(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, nsSize int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	ns1 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, nsSize)
+	ns2 := tf.NewAerospikeNamespaceWithDeviceStorage("aerospike-namespace-1", 1, 1, 0, nsSize)
+	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{ns1, ns2}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(int32(len(pods.Items))).To(Equal(nodeCount))
+
+	for _, pod := range pods.Items {
+		// every pod must have exactly one pvc per namespace
+		claimsByNamespace := make(map[string]int)
+		for _, volume := range pod.Spec.Volumes {
+			if volume.VolumeSource.PersistentVolumeClaim != nil {
+				claim, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).Get(context.TODO(), volume.VolumeSource.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				claimsByNamespace[claim.Labels[selectors.LabelNamespaceKey]]++
+
+				claimMode := claim.Spec.VolumeMode
+				switch claim.Labels[selectors.LabelNamespaceKey] {
+				case ns1.Name:
+					Expect(*claimMode).To(Equal(v1.PersistentVolumeFilesystem))
+				case ns2.Name:
+					Expect(*claimMode).To(Equal(v1.PersistentVolumeBlock))
+				}
+			}
+		}
+		Expect(claimsByNamespace).To(Equal(map[string]int{ns1.Name: 1, ns2.Name: 1}))
+	}
+
+	c, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	t, err := c.GetNamespaceStorageEngine(ns1.Name)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(t).To(Equal(common.StorageTypeFile))
+	t, err = c.GetNamespaceStorageEngine(ns2.Name)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(t).To(Equal(common.StorageTypeDevice))
+}
+
 func testVolumeIsReused(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32) {
 	Expect(nodeCount).To(BeNumerically(">", 1))
 	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
@@ -152,3 +202,232 @@ func testVolumeIsReused(tf *framework.TestFramework, ns *v1.Namespace, nodeCount
 		}
 	}
 }
+
+func testExpandNamespaceStorage(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, nsSize int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	ns1 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, nsSize)
+	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{ns1}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// decreasing the storage size must be rejected
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	smaller := res.DeepCopy()
+	smaller.Spec.Namespaces[0].Storage.Size = fmt.Sprintf("%dM", nsSize*512)
+	_, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), smaller, metav1.UpdateOptions{})
+	Expect(err).To(HaveOccurred())
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("cannot decrease the storage size for namespace")))
+
+	// increase the storage size
+	newSize := fmt.Sprintf("%dG", nsSize*2)
+	res.Spec.Namespaces[0].Storage.Size = newSize
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// wait for every pod to be using an expanded pvc
+	Eventually(func() (bool, error) {
+		pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+		if err != nil {
+			return false, err
+		}
+		if int32(len(pods.Items)) != nodeCount {
+			return false, nil
+		}
+		for _, pod := range pods.Items {
+			for _, volume := range pod.Spec.Volumes {
+				if volume.VolumeSource.PersistentVolumeClaim == nil {
+					continue
+				}
+				claim, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).Get(context.TODO(), volume.VolumeSource.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				capacity := claim.Status.Capacity[v1.ResourceStorage]
+				if capacity.Cmp(resource.MustParse(newSize)) < 0 {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	}, 20*time.Minute, 10*time.Second).Should(BeTrue())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure that aerospike is using the new size
+	c, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Close()
+	Eventually(func() (string, error) {
+		config, err := c.GetConfig(fmt.Sprintf("namespace;id=%s", ns1.Name))
+		return config["storage-engine.filesize"], err
+	}, 20*time.Minute, 10*time.Second).Should(Equal(fmt.Sprintf("%d", nsSize*2*1024*1024*1024)))
+}
+
+func testMigrateNamespaceStorage(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, nRecords int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	ns1 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 2, 1, 0, 1)
+	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{ns1}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	c1, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c1.WriteSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c1.Close()
+
+	// migrate the namespace from file to device storage
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	res.Spec.Namespaces[0].Storage.Type = common.StorageTypeDevice
+	res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// wait for every pod to be using a block device
+	Eventually(func() (bool, error) {
+		pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+		if err != nil {
+			return false, err
+		}
+		if int32(len(pods.Items)) != nodeCount {
+			return false, nil
+		}
+		for _, pod := range pods.Items {
+			for _, volume := range pod.Spec.Volumes {
+				if volume.VolumeSource.PersistentVolumeClaim == nil {
+					continue
+				}
+				claim, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).Get(context.TODO(), volume.VolumeSource.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				if claim.Spec.VolumeMode == nil || *claim.Spec.VolumeMode != v1.PersistentVolumeBlock {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	}, 30*time.Minute, 10*time.Second).Should(BeTrue())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure that aerospike is using the new storage type and that no
+	// data has been lost
+	c2, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c2.Close()
+	Eventually(func() (string, error) {
+		return c2.GetNamespaceStorageEngine(ns1.Name)
+	}, 10*time.Minute, 10*time.Second).Should(Equal(common.StorageTypeDevice))
+	err = c2.ReadSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func testMigrateNamespaceStorageWithoutReplication(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	res.Spec.Namespaces[0].Storage.Type = common.StorageTypeDevice
+	_, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Update(context.TODO(), res, metav1.UpdateOptions{})
+	Expect(err).To(HaveOccurred())
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("cannot migrate the storage for namespace")))
+}
+
+func testReplacePod(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, nRecords int) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	ns1 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 2, 1, 0, 1)
+	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{ns1}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	c1, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	err = c1.WriteSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+	c1.Close()
+
+	// record the pvcs used by every pod
+	claimNames := make(map[string]string, nodeCount)
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if c := volume.VolumeSource.PersistentVolumeClaim; c != nil {
+				claimNames[pod.Name] = c.ClaimName
+			}
+		}
+	}
+	Expect(claimNames).To(HaveLen(int(nodeCount)))
+
+	// request the replacement of the last pod
+	podName := fmt.Sprintf("%s-%d", res.Name, nodeCount-1)
+	pod, err := tf.KubeClient.CoreV1().Pods(ns.Name).Get(context.TODO(), podName, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	pod.Annotations[reconciler.ReplaceAnnotation] = "true"
+	_, err = tf.KubeClient.CoreV1().Pods(ns.Name).Update(context.TODO(), pod, metav1.UpdateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// wait for the pod to be re-created with a new pvc
+	Eventually(func() (string, error) {
+		pod, err := tf.KubeClient.CoreV1().Pods(ns.Name).Get(context.TODO(), podName, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+			return "", nil
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if c := volume.VolumeSource.PersistentVolumeClaim; c != nil {
+				return c.ClaimName, nil
+			}
+		}
+		return "", nil
+	}, 30*time.Minute, 10*time.Second).ShouldNot(Or(BeEmpty(), Equal(claimNames[podName])))
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure that the remaining pods were not restarted and still use the
+	// same pvcs
+	pods, err = tf.KubeClient.CoreV1().Pods(ns.Name).List(context.TODO(), listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	for _, pod := range pods.Items {
+		if pod.Name == podName {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if c := volume.VolumeSource.PersistentVolumeClaim; c != nil {
+				Expect(c.ClaimName).To(Equal(claimNames[pod.Name]))
+			}
+		}
+	}
+
+	// make sure that the old pvc has been marked as replaced
+	claim, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).Get(context.TODO(), claimNames[podName], metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(claim.Annotations).To(HaveKey(reconciler.ReplacedOnAnnotation))
+	Expect(claim.Annotations).To(HaveKey(reconciler.LastUnmountedOnAnnotation))
+
+	// make sure that no data has been lost
+	c2, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	defer c2.Close()
+	err = c2.ReadSequentialIntegers(ns1.Name, nRecords)
+	Expect(err).NotTo(HaveOccurred())
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
+)
+
+func testClusterStatusReportsNodes(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = 2
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, 2)
+	Expect(err).NotTo(HaveOccurred())
+
+	Eventually(func() (common.AerospikeClusterPhase, error) {
+		res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return res.Status.Phase, nil
+	}, 5*time.Minute, 5*time.Second).Should(Equal(common.AerospikeClusterPhaseRunning))
+
+	Expect(res.Status.ObservedGeneration).To(Equal(res.Generation))
+	Expect(apimeta.IsStatusConditionTrue(res.Status.Conditions, common.ConditionValid)).To(BeTrue())
+	Expect(res.Status.Operation).To(BeEmpty())
+	Expect(res.Status.ClusterKey).NotTo(BeEmpty())
+	Expect(res.Status.Nodes).To(HaveLen(2))
+	for _, node := range res.Status.Nodes {
+		Expect(node.PodName).NotTo(BeEmpty())
+		Expect(node.NodeID).NotTo(BeEmpty())
+		Expect(node.PodIP).NotTo(BeEmpty())
+		Expect(node.Ready).To(BeTrue())
+		Expect(node.Version).To(Equal(res.Spec.Version))
+		Expect(node.ClusterSize).To(Equal(2))
+	}
+}
+
+func testClusterStatusReportsValidationErrors(tf *framework.TestFramework, ns *v1.Namespace) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.Namespaces[0].Storage.StorageClassName = pointers.NewString("non-existing-storage-class")
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(context.TODO(), &aerospikeCluster, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	Eventually(func() (bool, error) {
+		res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Get(context.TODO(), res.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return apimeta.IsStatusConditionFalse(res.Status.Conditions, common.ConditionValid), nil
+	}, 2*time.Minute, 5*time.Second).Should(BeTrue())
+
+	condition := apimeta.FindStatusCondition(res.Status.Conditions, common.ConditionValid)
+	Expect(condition.Reason).To(Equal(events.ReasonValidationError))
+	Expect(condition.Message).To(ContainSubstring("non-existing-storage-class"))
+	Expect(res.Status.Nodes).To(BeEmpty())
+}
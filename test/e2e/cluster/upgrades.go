@@ -22,7 +22,7 @@ import (
 
 	. "github.com/onsi/gomega"
 	"k8s.io/api/core/v1"
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
@@ -75,7 +75,7 @@ func testReusePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework, n
 	asc, err = tf.UpgradeClusterAndWait(asc, targetVersion)
 	Expect(err).NotTo(HaveOccurred())
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(asc.Status.NodeCount))
 
@@ -109,15 +109,9 @@ func testReusePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework, n
 
 	// check if an AerospikeNamespaceBackup exists for each of the namespaces of the Aerospike cluster
 	for _, namespace := range asc.Spec.Namespaces {
-		backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Get(context.TODO(), reconciler.GetBackupName(namespace.Name, sourceVersion, targetVersion), metav1.GetOptions{})
+		backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Get(context.TODO(), reconciler.GetBackupName(asc.Name, namespace.Name, sourceVersion, targetVersion), metav1.GetOptions{})
 		Expect(err).NotTo(HaveOccurred())
-		completed := false
-		for _, condition := range backup.Status.Conditions {
-			if condition.Type == common.ConditionBackupFinished &&
-				condition.Status == apiextensions.ConditionTrue {
-				completed = true
-			}
-		}
+		completed := apimeta.IsStatusConditionTrue(backup.Status.Conditions, common.ConditionBackupFinished)
 		Expect(completed).To(Equal(true))
 	}
 }
@@ -163,7 +157,7 @@ func testRecreatePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework
 	asc, err = tf.UpgradeClusterAndWait(asc, targetVersion)
 	Expect(err).NotTo(HaveOccurred())
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(asc.Status.NodeCount))
 
@@ -197,15 +191,9 @@ func testRecreatePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework
 
 	// check if an AerospikeNamespaceBackup exists for each of the namespaces of the Aerospike cluster
 	for _, namespace := range asc.Spec.Namespaces {
-		backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Get(context.TODO(), reconciler.GetBackupName(namespace.Name, sourceVersion, targetVersion), metav1.GetOptions{})
+		backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Get(context.TODO(), reconciler.GetBackupName(asc.Name, namespace.Name, sourceVersion, targetVersion), metav1.GetOptions{})
 		Expect(err).NotTo(HaveOccurred())
-		completed := false
-		for _, condition := range backup.Status.Conditions {
-			if condition.Type == common.ConditionBackupFinished &&
-				condition.Status == apiextensions.ConditionTrue {
-				completed = true
-			}
-		}
+		completed := apimeta.IsStatusConditionTrue(backup.Status.Conditions, common.ConditionBackupFinished)
 		Expect(completed).To(Equal(true))
 	}
 }
@@ -145,3 +145,29 @@ func (ac *AerospikeClient) IsDataInMemoryEnabled(namespace string) (bool, error)
 	}
 	return false, fmt.Errorf("namespace has unknown storage type")
 }
+
+func (ac *AerospikeClient) GetConfig(context string) (map[string]string, error) {
+	c, err := as.NewConnection(&as.ClientPolicy{Timeout: 10 * time.Second}, &as.Host{Name: ac.host, Port: reconciler.ServicePort})
+	if err != nil {
+		return nil, err
+	}
+	infoCmd := fmt.Sprintf("get-config:context=%s", context)
+	r, err := as.RequestInfo(c, infoCmd)
+	if err != nil {
+		return nil, err
+	}
+	return asutils.ParseStatistics(r[infoCmd]), nil
+}
+
+func (ac *AerospikeClient) GetRacks(namespace string) (string, error) {
+	c, err := as.NewConnection(&as.ClientPolicy{Timeout: 10 * time.Second}, &as.Host{Name: ac.host, Port: reconciler.ServicePort})
+	if err != nil {
+		return "", err
+	}
+	infoCmd := fmt.Sprintf("racks:namespace=%s", namespace)
+	r, err := as.RequestInfo(c, infoCmd)
+	if err != nil {
+		return "", err
+	}
+	return r[infoCmd], nil
+}
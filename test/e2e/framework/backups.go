@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"time"
 
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	watchapi "k8s.io/apimachinery/pkg/watch"
@@ -161,14 +162,6 @@ func (tf *TestFramework) WaitForBackupRestoreCondition(obj aerospikev1alpha2.Bac
 func (tf *TestFramework) WaitForBackupRestoreCompleted(obj aerospikev1alpha2.BackupRestoreObject) error {
 	return tf.WaitForBackupRestoreCondition(obj, func(event watchapi.Event) (bool, error) {
 		obj := event.Object.(aerospikev1alpha2.BackupRestoreObject)
-		conditions := obj.GetConditions()
-		if conditions != nil {
-			for _, c := range conditions {
-				if c.Type == obj.GetFinishedConditionType() {
-					return true, nil
-				}
-			}
-		}
-		return false, nil
+		return apimeta.IsStatusConditionTrue(obj.GetConditions(), obj.GetFinishedConditionType()), nil
 	}, watchTimeout)
 }
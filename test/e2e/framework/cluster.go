@@ -148,6 +148,31 @@ func (tf *TestFramework) ChangeNamespaceMemorySizeAndScaleClusterAndWait(aerospi
 	return tf.WaitForClusterNodeCount(res, nodeCount)
 }
 
+func (tf *TestFramework) ChangeNamespacesAndWait(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespaces []aerospikev1alpha2.AerospikeNamespaceSpec) error {
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(aerospikeCluster.Namespace).Get(context.TODO(), aerospikeCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	res.Spec.Namespaces = namespaces
+	if res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(res.Namespace).Update(context.TODO(), res, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	return tf.WaitForClusterCondition(res, func(event watchapi.Event) (bool, error) {
+		// grab the current cluster object from the event
+		obj := event.Object.(*aerospikev1alpha2.AerospikeCluster)
+		// check whether .status.namespaces reflects the requested namespaces
+		if len(obj.Status.Namespaces) != len(namespaces) {
+			return false, nil
+		}
+		for i, ns := range namespaces {
+			if obj.Status.Namespaces[i].Name != ns.Name {
+				return false, nil
+			}
+		}
+		return true, nil
+	}, watchTimeout)
+}
+
 func (tf *TestFramework) NewAerospikeClusterV1alpha1(version string, nodeCount int32, namespaces []aerospikev1alpha1.AerospikeNamespaceSpec) aerospikev1alpha1.AerospikeCluster {
 	return aerospikev1alpha1.AerospikeCluster{
 		ObjectMeta: metav1.ObjectMeta{
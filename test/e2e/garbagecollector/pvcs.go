@@ -61,7 +61,7 @@ func testDeleteExpiredPVC(tf *framework.TestFramework, ns *v1.Namespace, finalNo
 	Expect(err).NotTo(HaveOccurred())
 	Expect(asc.Status.NodeCount).To(Equal(finalNodecount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(context.TODO(), fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(finalNodecount))
 